@@ -0,0 +1,261 @@
+// Package acme solves ACME DNS-01 challenges by publishing the challenge
+// TXT records on CloudFlare.
+//
+// Provider implements the challenge.Provider and challenge.ProviderTimeout
+// interfaces from github.com/go-acme/lego, so it can be used directly:
+//
+//	provider := acme.NewProvider(client, acme.WithPropagationWait())
+//	err := legoClient.Challenge.SetDNS01Provider(provider)
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/log"
+)
+
+// ErrZoneNotFound is returned when no zone on CloudFlare contains the
+// challenge record.
+var ErrZoneNotFound = errors.New("no zone found for domain")
+
+// Provider publishes ACME DNS-01 challenge records on CloudFlare.
+type Provider struct {
+	*settings
+
+	client *cfdns.Client
+
+	mu      sync.Mutex
+	records map[challengeKey]string // record IDs of the challenges presented
+}
+
+type challengeKey struct {
+	fqdn  string
+	value string
+}
+
+// NewProvider creates a provider that publishes challenge records using
+// the client.
+func NewProvider(client *cfdns.Client, opts ...Option) *Provider {
+	return &Provider{
+		settings: applyOptions(opts...),
+		client:   client,
+		records:  map[challengeKey]string{},
+	}
+}
+
+// ChallengeRecord returns the name and value of the TXT record for the
+// challenge of the domain, as defined by RFC 8555.
+func ChallengeRecord(domain, keyAuth string) (fqdn, value string) {
+	domain = strings.TrimSuffix(strings.TrimPrefix(domain, "*."), ".")
+	hash := sha256.Sum256([]byte(keyAuth))
+
+	return "_acme-challenge." + domain, base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// Present creates the TXT record for the challenge. It is equivalent to
+// PresentContext with a context that expires after the timeout configured
+// with WithTimeout.
+func (p *Provider) Present(domain, token, keyAuth string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	return p.PresentContext(ctx, domain, token, keyAuth)
+}
+
+// CleanUp deletes the TXT record created by Present. It is equivalent to
+// CleanUpContext with a context that expires after the timeout configured
+// with WithTimeout.
+func (p *Provider) CleanUp(domain, token, keyAuth string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	return p.CleanUpContext(ctx, domain, token, keyAuth)
+}
+
+// Timeout returns how long the ACME client should wait for the record to
+// propagate, and the interval between checks.
+func (p *Provider) Timeout() (timeout, interval time.Duration) {
+	return p.timeout, p.pollInterval
+}
+
+// PresentContext creates the TXT record for the challenge on the zone that
+// contains it. If WithPropagationWait was used, it also waits until the
+// record is served by the authoritative nameservers of the zone.
+func (p *Provider) PresentContext(ctx context.Context, domain, _, keyAuth string) error {
+	fqdn, value := ChallengeRecord(domain, keyAuth)
+
+	zone, err := p.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.CreateRecord(ctx, &cfdns.CreateRecordRequest{
+		ZoneID:  zone.ID,
+		Name:    fqdn,
+		Type:    "TXT",
+		Content: value,
+		Comment: p.comment,
+		TTL:     p.ttl,
+	})
+
+	var recordID string
+
+	switch {
+	case err == nil:
+		recordID = resp.ID
+
+		p.logger.I(fmt.Sprintf("Challenge record %s created on zone %s", fqdn, zone.Name))
+
+	case errors.Is(err, cfdns.ErrRecordAlreadyExists):
+		// e.g., created by a previous attempt; the error is only trusted
+		// if the record with the challenge value is found
+		recordIDs, findErr := p.findRecords(ctx, zone.ID, fqdn, value)
+		if findErr != nil {
+			return findErr
+		}
+
+		if len(recordIDs) == 0 {
+			return fmt.Errorf("creating challenge record %s: %w", fqdn, err)
+		}
+
+		recordID = recordIDs[0]
+
+		p.logger.I(fmt.Sprintf("Challenge record %s already exists on zone %s", fqdn, zone.Name))
+
+	default:
+		return fmt.Errorf("creating challenge record %s: %w", fqdn, err)
+	}
+
+	p.mu.Lock()
+	p.records[challengeKey{fqdn, value}] = recordID
+	p.mu.Unlock()
+
+	if !p.waitPropagation {
+		return nil
+	}
+
+	return p.waitForRecord(ctx, zone, fqdn, value)
+}
+
+// CleanUpContext deletes the TXT record created for the challenge. Other
+// TXT records with the same name, e.g., for other challenges of the same
+// domain, are not deleted.
+func (p *Provider) CleanUpContext(ctx context.Context, domain, _, keyAuth string) error {
+	fqdn, value := ChallengeRecord(domain, keyAuth)
+	key := challengeKey{fqdn, value}
+
+	zone, err := p.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	recordID, ok := p.records[key]
+	p.mu.Unlock()
+
+	recordIDs := []string{recordID}
+
+	if !ok {
+		// the record was not created by this provider, e.g., because the
+		// process was restarted; it is looked up by its value
+		recordIDs, err = p.findRecords(ctx, zone.ID, fqdn, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, id := range recordIDs {
+		_, err := p.client.DeleteRecord(ctx, &cfdns.DeleteRecordRequest{
+			ZoneID:   zone.ID,
+			RecordID: id,
+		})
+		if err != nil && !errors.Is(err, cfdns.ErrRecordNotFound) {
+			return fmt.Errorf("deleting challenge record %s: %w", fqdn, err)
+		}
+	}
+
+	p.mu.Lock()
+	delete(p.records, key)
+	p.mu.Unlock()
+
+	p.logger.I(fmt.Sprintf("Challenge record %s deleted from zone %s", fqdn, zone.Name),
+		log.WithInt("records", len(recordIDs)))
+
+	return nil
+}
+
+// findZone returns the zone that contains the name, trying the parent
+// domains from the longest to the shortest.
+func (p *Provider) findZone(ctx context.Context, fqdn string) (*cfdns.ListZonesResponseItem, error) {
+	labels := strings.Split(fqdn, ".")
+
+	for i := 1; i < len(labels)-1; i++ {
+		candidate := strings.Join(labels[i:], ".")
+
+		zones, err := cfdns.ReadAll(ctx, p.client.ListZones(&cfdns.ListZonesRequest{
+			Name: candidate,
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("looking up zone %s: %w", candidate, err)
+		}
+
+		if len(zones) > 0 {
+			return zones[0], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrZoneNotFound, fqdn)
+}
+
+func (p *Provider) findRecords(ctx context.Context, zoneID, fqdn, value string) ([]string, error) {
+	records, err := cfdns.ReadAll(ctx, cfdns.Filter(
+		p.client.ListRecords(&cfdns.ListRecordsRequest{
+			ZoneID: zoneID,
+			Name:   fqdn,
+			Type:   "TXT",
+		}),
+		func(rec *cfdns.ListRecordsResponseItem) bool {
+			return strings.Trim(rec.Content, `"`) == value
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("looking up challenge record %s: %w", fqdn, err)
+	}
+
+	ret := make([]string, len(records))
+	for i, rec := range records {
+		ret[i] = rec.ID
+	}
+
+	return ret, nil
+}
+
+// waitForRecord waits until all authoritative nameservers of the zone
+// serve the TXT record.
+func (p *Provider) waitForRecord(
+	ctx context.Context,
+	zone *cfdns.ListZonesResponseItem,
+	fqdn, value string,
+) error {
+	if len(zone.NameServers) == 0 {
+		return fmt.Errorf("zone %s has no nameservers assigned", zone.Name)
+	}
+
+	return p.client.WaitForPropagation(ctx, &cfdns.WaitForPropagationRequest{
+		ZoneID:       zone.ID,
+		Name:         fqdn,
+		Type:         "TXT",
+		Values:       []string{value},
+		Subset:       true, // other challenges may use the same name
+		NameServers:  zone.NameServers,
+		Resolver:     p.newResolver,
+		PollInterval: p.pollInterval,
+	})
+}
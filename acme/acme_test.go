@@ -0,0 +1,167 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/acme"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+	"github.com/simplesurance/cfdns/nsresolver"
+)
+
+func TestPresentCleanUp(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	fake.AddZone("example.org")
+	zoneID := fake.AddZone("example.com", "ns1.example.net", "ns2.example.net")
+
+	var lookups atomic.Int32
+
+	// the record becomes visible on the nameservers after a few queries
+	resolver := func(string) nsresolver.Resolver {
+		return &fakeResolver{lookupTXT: func(name string) ([]string, error) {
+			if lookups.Add(1) < 3 {
+				return nil, errors.New("no such host")
+			}
+
+			var ret []string
+
+			for _, rec := range fake.Records(zoneID) {
+				if rec.Name == name && rec.Type == "TXT" {
+					ret = append(ret, rec.Content)
+				}
+			}
+
+			return ret, nil
+		}}
+	}
+
+	client := fake.Client(t)
+	provider := acme.NewProvider(client,
+		acme.WithPropagationWait(),
+		acme.WithPollInterval(time.Millisecond),
+		acme.WithResolver(resolver))
+
+	// the wildcard and the base domain use the same record name
+	for _, domain := range []string{"www.sub.example.com", "*.www.sub.example.com"} {
+		err := provider.Present(domain, "token", domain+"-key-auth")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fqdn, value := acme.ChallengeRecord("www.sub.example.com", "www.sub.example.com-key-auth")
+
+	records := fake.Records(zoneID)
+	if len(records) != 2 || records[0].Name != fqdn || records[0].Content != value || records[0].TTL != 60 {
+		t.Fatalf("Unexpected records: %+v", records)
+	}
+
+	if lookups.Load() < 3 {
+		t.Errorf("Expected to wait for the record to be served by the nameservers")
+	}
+
+	err := provider.CleanUp("www.sub.example.com", "token", "www.sub.example.com-key-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a new provider, e.g., after a restart, finds the record by its value
+	err = acme.NewProvider(client).CleanUp("*.www.sub.example.com", "token", "*.www.sub.example.com-key-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if records := fake.Records(zoneID); len(records) != 0 {
+		t.Errorf("Expected challenge records to be deleted, got %+v", records)
+	}
+}
+
+func TestPresentZoneNotFound(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	fake.AddZone("example.org")
+
+	err := acme.NewProvider(fake.Client(t)).Present("www.example.com", "token", "key-auth")
+	if !errors.Is(err, acme.ErrZoneNotFound) {
+		t.Errorf("Expected ErrZoneNotFound, got %v", err)
+	}
+}
+
+func TestPresentExistingRecord(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+
+	fqdn, value := acme.ChallengeRecord("www.example.com", "key-auth")
+	fake.AddRecord(zoneID, fakecf.Record{Name: fqdn, Type: "TXT", Content: value, TTL: 60})
+
+	provider := acme.NewProvider(fake.Client(t))
+
+	err := provider.Present("www.example.com", "token", "key-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = provider.CleanUp("www.example.com", "token", "key-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if records := fake.Records(zoneID); len(records) != 0 {
+		t.Errorf("Expected the existing challenge record to be deleted, got %+v", records)
+	}
+}
+
+func TestPresentExistsNotFound(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+
+	// CloudFlare reports an existing record, but it is not listed
+	client := fakecf.NewClient(t, fakecf.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodPost {
+			return fakecf.JSONResponse(http.StatusBadRequest, fmt.Sprintf(
+				`{"success":false,"errors":[{"code":%d,"message":"An identical record already exists."}]}`,
+				fakecf.CodeRecordExists)), nil
+		}
+
+		return fake.RoundTrip(req)
+	}))
+
+	err := acme.NewProvider(client).Present("www.example.com", "token", "key-auth")
+	if !errors.Is(err, cfdns.ErrRecordAlreadyExists) {
+		t.Errorf("Expected ErrRecordAlreadyExists, got %v", err)
+	}
+
+	if records := fake.Records(zoneID); len(records) != 0 {
+		t.Errorf("Expected no records, got %+v", records)
+	}
+}
+
+type fakeResolver struct {
+	lookupTXT func(name string) ([]string, error)
+}
+
+func (r *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return r.lookupTXT(name)
+}
+
+func (r *fakeResolver) LookupNetIP(context.Context, string, string) ([]netip.Addr, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (r *fakeResolver) LookupCNAME(context.Context, string) (string, error) {
+	return "", errors.ErrUnsupported
+}
@@ -0,0 +1,93 @@
+package acme
+
+import (
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/niltarget"
+	"github.com/simplesurance/cfdns/nsresolver"
+)
+
+type Option func(*settings)
+
+type settings struct {
+	ttl             time.Duration
+	comment         string
+	timeout         time.Duration
+	pollInterval    time.Duration
+	waitPropagation bool
+	newResolver     nsresolver.NewFunc
+	logger          *log.Logger
+}
+
+func applyOptions(opts ...Option) *settings {
+	ret := settings{
+		ttl:          time.Minute, // minimum TTL allowed by CloudFlare
+		comment:      "ACME challenge created by cfdns",
+		timeout:      5 * time.Minute,
+		pollInterval: 5 * time.Second,
+		newResolver:  nsresolver.New,
+		logger:       log.New(niltarget.New()), // by default log messages are suppressed
+	}
+	for _, opt := range opts {
+		opt(&ret)
+	}
+
+	return &ret
+}
+
+// WithTTL configures the TTL of the challenge records. The default is 1
+// minute, the minimum allowed by CloudFlare.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *settings) {
+		s.ttl = ttl
+	}
+}
+
+// WithComment configures the comment added to challenge records, to
+// identify them on CloudFlare.
+func WithComment(comment string) Option {
+	return func(s *settings) {
+		s.comment = comment
+	}
+}
+
+// WithTimeout configures how long Present and CleanUp may take, and how
+// long the ACME client waits for the record to propagate. The default is
+// 5 minutes.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *settings) {
+		s.timeout = timeout
+	}
+}
+
+// WithPollInterval configures the interval between checks if the record
+// propagated. The default is 5 seconds.
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *settings) {
+		s.pollInterval = interval
+	}
+}
+
+// WithPropagationWait makes Present wait until the challenge record is
+// served by all authoritative nameservers of the zone.
+func WithPropagationWait() Option {
+	return func(s *settings) {
+		s.waitPropagation = true
+	}
+}
+
+// WithResolver configures how the authoritative nameservers are queried
+// when waiting for the record to propagate. The default is
+// nsresolver.New.
+func WithResolver(newResolver nsresolver.NewFunc) Option {
+	return func(s *settings) {
+		s.newResolver = newResolver
+	}
+}
+
+func WithLogger(logger *log.Logger) Option {
+	return func(s *settings) {
+		s.logger = logger
+	}
+}
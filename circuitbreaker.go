@@ -0,0 +1,291 @@
+package cfdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/retry"
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenDuration     = 30 * time.Second
+	defaultCircuitHalfOpenProbes   = 1
+)
+
+// ErrCircuitOpen is matched by errors.Is on errors returned while the
+// circuit breaker is open. No request is sent to CloudFlare in this case.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned when a request is rejected by the circuit
+// breaker without being sent to CloudFlare.
+type CircuitOpenError struct {
+	// Until is when the circuit breaker will start allowing probe
+	// requests again.
+	Until time.Time
+}
+
+func (e CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v until %s", ErrCircuitOpen, e.Until.Format(time.RFC3339))
+}
+
+func (e CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+var _ error = CircuitOpenError{}
+
+// CircuitState is the state of the circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed is the normal state, all requests are sent.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen is the state after too many consecutive failures. All
+	// requests fail immediately with ErrCircuitOpen.
+	CircuitOpen
+
+	// CircuitHalfOpen is the state after the circuit was open for long
+	// enough. A limited amount of probe requests are sent; if they succeed
+	// the circuit is closed, otherwise it is opened again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	return circuitStateToString[s]
+}
+
+var circuitStateToString = [...]string{
+	CircuitClosed:   "closed",
+	CircuitOpen:     "open",
+	CircuitHalfOpen: "half-open",
+}
+
+// CircuitBreakerSettings configures the circuit breaker enabled with
+// WithCircuitBreaker. Zero values are replaced by defaults.
+type CircuitBreakerSettings struct {
+	// FailureThreshold is how many consecutive retryable failures open
+	// the circuit. Retryable failures are network errors, request
+	// timeouts, HTTP 429 and HTTP 5xx responses. Default is 5.
+	FailureThreshold int
+
+	// OpenDuration is how long the circuit stays open before probe
+	// requests are allowed. Default is 30 seconds.
+	OpenDuration time.Duration
+
+	// HalfOpenProbes is how many probe requests may be in-flight
+	// concurrently while the circuit is half-open. Default is 1.
+	HalfOpenProbes int
+
+	// OnStateChange, if not nil, is called every time the state of the
+	// circuit breaker changes. It is called synchronously, after the
+	// change, and must not block.
+	OnStateChange func(from, to CircuitState)
+}
+
+type circuitBreaker struct {
+	settings CircuitBreakerSettings
+	logger   *log.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+func newCircuitBreaker(cfg *CircuitBreakerSettings, logger *log.Logger) *circuitBreaker {
+	if cfg == nil {
+		return nil
+	}
+
+	ret := &circuitBreaker{
+		settings: *cfg,
+		logger:   logger.SubLogger(log.WithPrefix("CircuitBreaker")),
+		now:      time.Now,
+	}
+
+	if ret.settings.FailureThreshold <= 0 {
+		ret.settings.FailureThreshold = defaultCircuitFailureThreshold
+	}
+
+	if ret.settings.OpenDuration <= 0 {
+		ret.settings.OpenDuration = defaultCircuitOpenDuration
+	}
+
+	if ret.settings.HalfOpenProbes <= 0 {
+		ret.settings.HalfOpenProbes = defaultCircuitHalfOpenProbes
+	}
+
+	return ret
+}
+
+// allow returns an error if the request must not be sent. Otherwise it
+// returns a function that must be called with the result of the request.
+// A nil circuit breaker allows all requests.
+func (cb *circuitBreaker) allow(ctx context.Context) (done func(error), _ error) {
+	if cb == nil {
+		return func(error) {}, nil
+	}
+
+	cb.mu.Lock()
+
+	from := cb.state
+
+	if cb.state == CircuitOpen {
+		until := cb.openedAt.Add(cb.settings.OpenDuration)
+		if cb.now().Before(until) {
+			cb.mu.Unlock()
+			return nil, CircuitOpenError{Until: until}
+		}
+
+		cb.state = CircuitHalfOpen
+		cb.probes = 0
+	}
+
+	isProbe := false
+
+	if cb.state == CircuitHalfOpen {
+		if cb.probes >= cb.settings.HalfOpenProbes {
+			cb.mu.Unlock()
+			cb.notify(ctx, from, CircuitHalfOpen)
+
+			return nil, CircuitOpenError{Until: cb.now()}
+		}
+
+		cb.probes++
+		isProbe = true
+	}
+
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(ctx, from, to)
+
+	return func(err error) {
+		cb.report(ctx, isProbe, err)
+	}, nil
+}
+
+func (cb *circuitBreaker) report(ctx context.Context, isProbe bool, err error) {
+	cb.mu.Lock()
+
+	from := cb.state
+
+	if isProbe {
+		cb.probes--
+	}
+
+	switch classifyCircuitResult(ctx, err) {
+	case circuitSuccess:
+		cb.failures = 0
+		if cb.state == CircuitHalfOpen && isProbe {
+			cb.state = CircuitClosed
+		}
+	case circuitFailure:
+		switch cb.state {
+		case CircuitClosed:
+			cb.failures++
+			if cb.failures >= cb.settings.FailureThreshold {
+				cb.open()
+			}
+		case CircuitHalfOpen:
+			cb.open()
+		case CircuitOpen:
+			// already open; nothing to do
+		}
+	case circuitNeutral:
+		// the result says nothing about the health of CloudFlare
+	}
+
+	to := cb.state
+	cb.mu.Unlock()
+
+	if err != nil {
+		cb.notify(ctx, from, to, log.WithError(err))
+	} else {
+		cb.notify(ctx, from, to)
+	}
+}
+
+// open changes the state to open. Must be called with the lock held.
+func (cb *circuitBreaker) open() {
+	cb.state = CircuitOpen
+	cb.openedAt = cb.now()
+	cb.failures = 0
+}
+
+// notify logs and reports a state change. The entry has the tags of the
+// context of the request that caused the change.
+func (cb *circuitBreaker) notify(ctx context.Context, from, to CircuitState, opts ...log.Option) {
+	if from == to {
+		return
+	}
+
+	opts = append(opts,
+		log.WithString("from", from.String()),
+		log.WithString("to", to.String()))
+
+	logger := cb.logger.SubLogger(log.TagsFromContext(ctx)...)
+
+	msg := fmt.Sprintf("Circuit breaker changed from %s to %s", from, to)
+	if to == CircuitOpen {
+		logger.W(msg, opts...)
+	} else {
+		logger.I(msg, opts...)
+	}
+
+	if cb.settings.OnStateChange != nil {
+		cb.settings.OnStateChange(from, to)
+	}
+}
+
+type circuitResult int
+
+const (
+	circuitSuccess circuitResult = iota
+	circuitFailure
+	circuitNeutral
+)
+
+// classifyCircuitResult determines if the result of a request indicates
+// that CloudFlare is healthy or not. Errors caused by the caller, like
+// context cancellation or invalid requests, do not count as failures.
+func classifyCircuitResult(ctx context.Context, err error) circuitResult {
+	if err == nil {
+		return circuitSuccess
+	}
+
+	if ctx.Err() != nil {
+		return circuitNeutral
+	}
+
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.Code >= 500 || httpErr.Code == 429 {
+			return circuitFailure
+		}
+
+		// CloudFlare answered; the request itself is not acceptable
+		return circuitSuccess
+	}
+
+	// the request timeout configured with WithRequestTimeout expired
+	if errors.Is(err, context.DeadlineExceeded) {
+		return circuitFailure
+	}
+
+	// other permanent errors are not caused by CloudFlare being unavailable
+	var permErr retry.PermanentError
+	if errors.As(err, &permErr) {
+		return circuitNeutral
+	}
+
+	return circuitFailure
+}
@@ -0,0 +1,121 @@
+package cfdns
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/testtarget"
+	"github.com/simplesurance/cfdns/retry"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	netErr := errors.New("connection reset by peer")
+
+	var changes []CircuitState
+
+	cb := newCircuitBreaker(&CircuitBreakerSettings{
+		FailureThreshold: 2,
+		OpenDuration:     time.Minute,
+		OnStateChange: func(_, to CircuitState) {
+			changes = append(changes, to)
+		},
+	}, log.New(testtarget.ForTest(t, true)))
+	cb.now = func() time.Time { return now }
+
+	send := func(result error) error {
+		done, err := cb.allow(ctx)
+		if err != nil {
+			return err
+		}
+
+		done(result)
+
+		return nil
+	}
+
+	// a client error shows that CloudFlare is reachable, so it resets the
+	// count of consecutive failures
+	mustNotFail(t, send(netErr))
+	mustNotFail(t, send(retry.PermanentError{Cause: HTTPError{Code: 400}}))
+	mustNotFail(t, send(netErr))
+	assertState(t, cb, CircuitClosed)
+
+	// second consecutive failure after the client error
+	mustNotFail(t, send(HTTPError{Code: 503}))
+	assertState(t, cb, CircuitOpen)
+
+	err := send(nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	// only one probe at a time is allowed in half-open state
+	now = now.Add(time.Minute)
+
+	probeDone, err := cb.allow(ctx)
+	mustNotFail(t, err)
+	assertState(t, cb, CircuitHalfOpen)
+
+	if err := send(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen while probing, got %v", err)
+	}
+
+	// failed probe opens the circuit again
+	probeDone(netErr)
+	assertState(t, cb, CircuitOpen)
+
+	// successful probe closes it
+	now = now.Add(time.Minute)
+
+	mustNotFail(t, send(nil))
+	assertState(t, cb, CircuitClosed)
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected state changes %v, got %v", want, changes)
+	}
+
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("Expected state changes %v, got %v", want, changes)
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cb := newCircuitBreaker(&CircuitBreakerSettings{FailureThreshold: 1},
+		log.New(testtarget.ForTest(t, true)))
+
+	done, err := cb.allow(ctx)
+	mustNotFail(t, err)
+	done(ctx.Err())
+
+	assertState(t, cb, CircuitClosed)
+}
+
+func assertState(t *testing.T, cb *circuitBreaker, want CircuitState) {
+	t.Helper()
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != want {
+		t.Fatalf("Expected circuit breaker to be %s, but it is %s", want, cb.state)
+	}
+}
+
+func mustNotFail(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
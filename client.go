@@ -12,10 +12,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"mime"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -29,7 +32,12 @@ const (
 	retryFactor       = 2
 	retryMaxAttempts  = 6
 	itemsPerPage      = 500
+	zonesPerPage      = MaxZonesPerPage
 	maxResponseLength = 1024 * 1024
+
+	// maxRecordLength is the size allowed for each record in responses
+	// listing records; it is the size allowed for the default page size.
+	maxRecordLength = maxResponseLength / itemsPerPage
 )
 
 var errResponseTooLarge = retry.PermanentError{
@@ -39,22 +47,60 @@ var errResponseTooLarge = retry.PermanentError{
 type Client struct {
 	*settings
 
-	creds Credentials
+	creds     Credentials
+	breaker   *circuitBreaker
+	scheduler *priorityLimiter
 }
 
 func NewClient(creds Credentials, options ...Option) *Client {
+	settings := applyOptions(options...)
+
 	ret := Client{
-		settings: applyOptions(options...),
-		creds:    creds,
+		settings:  settings,
+		creds:     creds,
+		breaker:   newCircuitBreaker(settings.circuitBreaker, settings.logger),
+		scheduler: newPriorityLimiter(settings.ratelim),
 	}
 
 	return &ret
 }
 
+// contextLogger returns the logger of the client with the tags added to
+// the context with log.ContextWithTags.
+func (c *Client) contextLogger(ctx context.Context) *log.Logger {
+	return c.logger.SubLogger(log.TagsFromContext(ctx)...)
+}
+
+// externalCaller returns the innermost frame of the stack that is not from
+// this package or from the go runtime, that is the code using the client.
+// It returns false when there is none, e.g., on goroutines started by the
+// client to prefetch pages.
+func externalCaller() (log.Caller, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+
+		if !strings.HasPrefix(frame.Function, packagePath+".") &&
+			!strings.HasPrefix(frame.Function, "runtime.") {
+			return log.Caller{File: frame.File, Line: frame.Line}, true
+		}
+
+		if !more {
+			return log.Caller{}, false
+		}
+	}
+}
+
 // sendRequestRetry tries sending the request until it succeeds, fail to
 // many times of fails once with a permanent error. Wait between retries
 // use exponential backoff.
 //
+// Tags added to the context with log.ContextWithTags are included on all
+// log entries and on the returned error.
+//
 // This is not a method of Client because go allows using a type parameter
 // on a method, but not declaring them.
 func sendRequestRetry[TRESP commonResponseSetter](
@@ -68,16 +114,68 @@ func sendRequestRetry[TRESP commonResponseSetter](
 ) {
 	var resp *response[TRESP]
 
-	reterr := retry.ExpBackoff(ctx, logger, retryFirstDelay, retryMaxDelay,
-		retryFactor, retryMaxAttempts, func() error {
-			var err error
+	ctxTags := log.TagsFromContext(ctx)
+	logger = logger.SubLogger(ctxTags...)
 
-			resp, err = sendRequest[TRESP](ctx, client, logger, req)
+	// entries point to the code that called the client, not to the
+	// retry logic or other internals
+	if caller, ok := externalCaller(); ok {
+		logger = logger.SubLogger(log.WithCaller(caller))
+	}
 
-			return err
-		})
+	op := &Operation{
+		Name:       req.operation,
+		ZoneID:     req.zoneID,
+		RecordType: req.recordType,
+		Method:     req.method,
+		Path:       req.path,
+	}
+
+	reterr := interceptOperation(ctx, client.interceptors, op, func(ctx context.Context) error {
+		start := time.Now()
+
+		err := retry.ExpBackoff(ctx, logger, retryFirstDelay, retryMaxDelay,
+			retryFactor, retryMaxAttempts, func() error {
+				done, err := client.breaker.allow(ctx)
+				if err != nil {
+					return retry.PermanentError{Cause: err}
+				}
+
+				op.Attempts++
+
+				resp, err = sendRequest[TRESP](ctx, client, logger, req, op)
+				done(err)
 
-	return resp, reterr
+				return err
+			})
+
+		op.Latency = time.Since(start)
+		op.Err = err
+
+		return err
+	})
+	if reterr != nil {
+		return nil, withContextTags(reterr, ctxTags)
+	}
+
+	return resp, nil
+}
+
+// withContextTags adds the values of the tags to the error message.
+func withContextTags(err error, tags []log.Option) error {
+	if len(tags) == 0 {
+		return err
+	}
+
+	values := log.TagValues(tags...)
+	keys := slices.Sorted(maps.Keys(values))
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, values[k])
+	}
+
+	return fmt.Errorf("%w [%s]", err, strings.Join(pairs, " "))
 }
 
 // sendRequest sends an HTTP request, parses and returns the response.
@@ -89,15 +187,20 @@ func sendRequest[TRESP commonResponseSetter](
 	client *Client,
 	logger *log.Logger,
 	treq *request,
+	op *Operation,
 ) (
 	*response[TRESP],
 	error,
 ) {
-	err := client.ratelim.Wait(ctx)
+	waitStart := time.Now()
+
+	err := client.scheduler.Wait(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	limiterWait := time.Since(waitStart)
+
 	// request body
 	var reqBody []byte
 	if treq.body != nil {
@@ -127,8 +230,52 @@ func sendRequest[TRESP commonResponseSetter](
 	// headers
 	req.Header.Set("Content-Type", "application/json")
 
-	// credentials
-	reqNoAuth := req.Clone(ctx)
+	att := &Attempt{
+		Operation:   op,
+		Number:      op.Attempts,
+		Request:     req,
+		LimiterWait: limiterWait,
+	}
+
+	var tresp *response[TRESP]
+
+	err = interceptAttempt(reqCtx, client.interceptors, att, func(ctx context.Context) error {
+		start := time.Now()
+
+		var err error
+
+		tresp, err = sendHTTPRequest[TRESP](ctx, client, logger, att, reqBody, treq.responseLimit())
+
+		att.Latency = time.Since(start)
+		att.Err = err
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tresp, nil
+}
+
+// sendHTTPRequest sends the request from the attempt, adding credentials
+// to it, and handles the response.
+func sendHTTPRequest[TRESP commonResponseSetter](
+	ctx context.Context,
+	client *Client,
+	logger *log.Logger,
+	att *Attempt,
+	reqBody []byte,
+	responseLimit int,
+) (
+	*response[TRESP],
+	error,
+) {
+	// credentials; interceptors might have modified the request, so the
+	// version without credentials used for logging is the one from the
+	// attempt
+	reqNoAuth := att.Request
+	req := reqNoAuth.Clone(ctx)
 	client.creds.configure(req)
 
 	// send the request
@@ -143,6 +290,12 @@ func sendRequest[TRESP commonResponseSetter](
 		return nil, err // allow retry
 	}
 
+	att.Response = resp
+
+	if rayID := resp.Header.Get(cfRayHeader); rayID != "" {
+		logger = logger.SubLogger(log.WithString("cf-ray", rayID))
+	}
+
 	defer func() {
 		_ = resp.Body.Close()
 	}()
@@ -150,25 +303,34 @@ func sendRequest[TRESP commonResponseSetter](
 	// handle response
 	if resp.StatusCode >= 400 {
 		err = handleErrorResponse(resp, logger)
-		logFullRequestResponse(logger, reqNoAuth, reqBody, resp, rawResponseFromErr(err))
+		att.ResponseSize = responseSizeFromErr(err)
+		logFullRequestResponse(logger, &client.redaction, reqNoAuth, reqBody, resp, rawResponseFromErr(err))
 
 		return nil, err
 	}
 
-	tresp, err := handleSuccessResponse[TRESP](resp, logger)
+	tresp, err := handleSuccessResponse[TRESP](resp, responseLimit, logger)
 	if err != nil {
-		logFullRequestResponse(logger, reqNoAuth, reqBody, resp, rawResponseFromErr(err))
+		att.ResponseSize = responseSizeFromErr(err)
+		logFullRequestResponse(logger, &client.redaction, reqNoAuth, reqBody, resp, rawResponseFromErr(err))
+
 		return nil, err
 	}
 
+	att.ResponseSize = len(tresp.rawBody)
+
+	for _, msg := range tresp.body.cfMessages() {
+		logger.I("CloudFlare returned a message: " + msg.String())
+	}
+
 	if client.logSuccess {
-		logFullRequestResponse(logger, reqNoAuth, reqBody, resp, tresp.rawBody)
+		logFullRequestResponse(logger, &client.redaction, reqNoAuth, reqBody, resp, tresp.rawBody)
 	}
 
 	return tresp, err
 }
 
-func handleSuccessResponse[TRESP commonResponseSetter](httpResp *http.Response, logger *log.Logger) (
+func handleSuccessResponse[TRESP commonResponseSetter](httpResp *http.Response, limit int, logger *log.Logger) (
 	*response[TRESP],
 	error,
 ) {
@@ -179,7 +341,7 @@ func handleSuccessResponse[TRESP commonResponseSetter](httpResp *http.Response,
 
 	var err error
 
-	ret.rawBody, err = readResponseBody(httpResp.Body)
+	ret.rawBody, err = readResponseBody(httpResp.Body, limit)
 	if err != nil {
 		// error response already specifies is can retry or not
 		return nil, errors.Join(err, HTTPError{
@@ -189,8 +351,8 @@ func handleSuccessResponse[TRESP commonResponseSetter](httpResp *http.Response,
 		})
 	}
 
-	if len(ret.rawBody) == maxResponseLength {
-		logger.W(fmt.Sprintf("Response from CloudFlare rejected because is bigger than %d", maxResponseLength))
+	if len(ret.rawBody) == limit {
+		logger.W(fmt.Sprintf("Response from CloudFlare rejected because is bigger than %d", limit))
 
 		return nil, retry.PermanentError{
 			Cause: errors.Join(err, HTTPError{
@@ -221,7 +383,7 @@ func handleErrorResponse(resp *http.Response, _ *log.Logger) error {
 		Headers: resp.Header,
 	}
 
-	respBody, err := readResponseBody(resp.Body)
+	respBody, err := readResponseBody(resp.Body, maxResponseLength)
 	if err != nil {
 		err := fmt.Errorf("CloudFlare returned an error, but failed to read the error body: %w; %w", err, httpErr)
 
@@ -267,6 +429,7 @@ func handleErrorResponse(resp *http.Response, _ *log.Logger) error {
 
 func logFullRequestResponse(
 	logger *log.Logger,
+	redaction *Redaction,
 	req *http.Request,
 	reqBody []byte,
 	resp *http.Response,
@@ -276,16 +439,22 @@ func logFullRequestResponse(
 		msg := &strings.Builder{}
 
 		// request
-		reqDump, _ := httputil.DumpRequestOut(req, false)
+		redactedReq := req.Clone(req.Context())
+		redactedReq.Header = redaction.header(req.Header)
+
+		reqDump, _ := httputil.DumpRequestOut(redactedReq, false)
 		_, _ = msg.Write(reqDump)
-		_, _ = msg.Write(reqBody)
+		_, _ = msg.Write(redaction.body(reqBody))
 		fmt.Fprintln(msg)
 		fmt.Fprintln(msg)
 
 		// response
-		respDump, _ := httputil.DumpResponse(resp, false)
+		redactedResp := *resp
+		redactedResp.Header = redaction.header(resp.Header)
+
+		respDump, _ := httputil.DumpResponse(&redactedResp, false)
 		_, _ = msg.Write(respDump)
-		_, _ = msg.Write(respBody)
+		_, _ = msg.Write(redaction.body(respBody))
 
 		log("Successful request to CloudFlare:\n" + msg.String())
 	})
@@ -306,13 +475,13 @@ func requestURL(treq *request) string {
 	return theurl.String()
 }
 
-func readResponseBody(body io.Reader) ([]byte, error) {
-	ret, err := io.ReadAll(io.LimitReader(body, maxResponseLength+1))
+func readResponseBody(body io.Reader, limit int) ([]byte, error) {
+	ret, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
 	if err != nil {
 		return nil, err // allow retry
 	}
 
-	if len(ret) > maxResponseLength {
+	if len(ret) > limit {
 		return nil, errResponseTooLarge // permanent error
 	}
 
@@ -328,3 +497,12 @@ func rawResponseFromErr(err error) []byte {
 	// if the error is not an HTTPError return instead the error's details
 	return fmt.Appendf(nil, "%v", err)
 }
+
+func responseSizeFromErr(err error) int {
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return len(httpErr.RawBody)
+	}
+
+	return 0
+}
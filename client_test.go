@@ -149,19 +149,19 @@ func TestConflict(t *testing.T) {
 	client, testZoneID := getClient(ctx, t)
 
 	cases := []*struct {
-		typ           string
-		content       string
-		wantErrorCode int
+		typ     string
+		content string
+		wantErr error
 	}{
 		{
-			typ:           "CNAME",
-			content:       "github.com",
-			wantErrorCode: 81053,
+			typ:     "CNAME",
+			content: "github.com",
+			wantErr: cfdns.ErrCNAMEConflict,
 		},
 		{
-			typ:           "A",
-			content:       "1.1.1.1",
-			wantErrorCode: 81058,
+			typ:     "A",
+			content: "1.1.1.1",
+			wantErr: cfdns.ErrRecordAlreadyExists,
 		},
 	}
 
@@ -196,14 +196,8 @@ func TestConflict(t *testing.T) {
 				Comment: comment,
 			})
 
-			var cferr cfdns.CloudFlareError
-			if err == nil || !errors.As(err, &cferr) {
-				t.Fatalf("Expected cfdns.CloudFlareError, got %v", err)
-			}
-
-			if !cferr.IsAnyCFErrorCode(tc.wantErrorCode) {
-				t.Errorf("Expected CloudFlare error %d, got %v",
-					tc.wantErrorCode, cferr)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Expected %v, got %v", tc.wantErr, err)
 			}
 		})
 	}
@@ -0,0 +1,160 @@
+package cfdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrStopIteration can be returned by the function passed to ForEach to
+// stop iterating without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// ErrTooManyItems is matched by errors.Is on errors returned by CollectN
+// when the iterator has more items than allowed.
+var ErrTooManyItems = errors.New("too many items")
+
+// Filter returns an iterator with the items from it for which keep returns
+// true.
+func Filter[T any](it *Iterator[T], keep func(*T) bool) *Iterator[T] {
+	return derive(it, func(ctx context.Context) (*T, error) {
+		for {
+			item, err := it.Next(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			if keep(item) {
+				return item, nil
+			}
+		}
+	})
+}
+
+// Map returns an iterator with the result of calling fn on each item from
+// it.
+func Map[T, U any](it *Iterator[T], fn func(*T) *U) *Iterator[U] {
+	return derive(it, func(ctx context.Context) (*U, error) {
+		item, err := it.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return fn(item), nil
+	})
+}
+
+// Take returns an iterator with at most n items from it. No more pages are
+// fetched after n items were returned.
+func Take[T any](it *Iterator[T], n int) *Iterator[T] {
+	taken := 0
+
+	return derive(it, func(ctx context.Context) (*T, error) {
+		if taken >= n {
+			it.Close()
+			return nil, io.EOF
+		}
+
+		item, err := it.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		taken++
+
+		return item, nil
+	})
+}
+
+// CollectN reads all items from the iterator, like ReadAll, but fails with
+// an error matching ErrTooManyItems if there are more than n items. At most
+// n+1 items are read.
+func CollectN[T any](ctx context.Context, it *Iterator[T], n int) ([]*T, error) {
+	defer it.Close()
+
+	ret := []*T{}
+
+	for {
+		item, err := it.Next(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return ret, nil
+			}
+
+			return nil, err
+		}
+
+		if len(ret) == n {
+			return nil, fmt.Errorf("%w: more than %d", ErrTooManyItems, n)
+		}
+
+		ret = append(ret, item)
+	}
+}
+
+// ForEach calls fn for each item from the iterator. If fn returns an error
+// the iteration stops and the error is returned, unless it is
+// ErrStopIteration, in which case nil is returned.
+func ForEach[T any](ctx context.Context, it *Iterator[T], fn func(*T) error) error {
+	defer it.Close()
+
+	for {
+		item, err := it.Next(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		err = fn(item)
+		if err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+// Count returns how many items the iterator has left to return. For
+// iterators returned by the client the total amount of items reported by
+// CloudFlare is used, so at most one page is fetched, and the iterator can
+// still be used to read the items. Iterators created with Filter, Map and
+// Take must be read until the end to count their items.
+func Count[T any](ctx context.Context, it *Iterator[T]) (int, error) {
+	if it.next == nil {
+		err := it.fill(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		if it.isLast {
+			return len(it.elements), nil
+		}
+
+		return it.total - it.Cursor().Read, nil
+	}
+
+	ret := 0
+
+	err := ForEach(ctx, it, func(*T) error {
+		ret++
+		return nil
+	})
+
+	return ret, err
+}
+
+func derive[T, U any](it *Iterator[T], next func(context.Context) (*U, error)) *Iterator[U] {
+	return &Iterator[U]{
+		next: next,
+		source: &derivedSource{
+			cursor: it.Cursor,
+			close:  it.Close,
+		},
+	}
+}
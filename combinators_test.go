@@ -0,0 +1,150 @@
+package cfdns_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/simplesurance/cfdns"
+)
+
+// newZonesClient creates a client that lists the amount of zones provided,
+// counting the requests sent.
+func newZonesClient(t *testing.T, total int, requests *atomic.Int32) *cfdns.Client {
+	t.Helper()
+
+	return newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+
+		return zonesPage(page, perPage, total), nil
+	})
+}
+
+func TestCombinators(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var requests atomic.Int32
+
+	client := newZonesClient(t, 1200, &requests)
+
+	names, err := cfdns.ReadAll(ctx, cfdns.Take(
+		cfdns.Map(
+			cfdns.Filter(client.ListZones(&cfdns.ListZonesRequest{}), func(z *cfdns.ListZonesResponseItem) bool {
+				return strings.HasSuffix(z.Name, "0")
+			}),
+			func(z *cfdns.ListZonesResponseItem) *string { return &z.Name }),
+		3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 3 || *names[0] != "zone00000000" || *names[2] != "zone00000020" {
+		t.Errorf("Unexpected result: %v", names)
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected Take to stop fetching pages, got %d requests", n)
+	}
+}
+
+func TestCollectN(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var requests atomic.Int32
+
+	client := newZonesClient(t, 600, &requests)
+
+	zones, err := cfdns.CollectN(ctx, client.ListZones(&cfdns.ListZonesRequest{}), 600)
+	if err != nil || len(zones) != 600 {
+		t.Errorf("Expected 600 zones, got %d, %v", len(zones), err)
+	}
+
+	_, err = cfdns.CollectN(ctx, client.ListZones(&cfdns.ListZonesRequest{}), 599)
+	if !errors.Is(err, cfdns.ErrTooManyItems) {
+		t.Errorf("Expected ErrTooManyItems, got %v", err)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var requests atomic.Int32
+
+	client := newZonesClient(t, 1200, &requests)
+
+	seen := 0
+
+	err := cfdns.ForEach(ctx, client.ListZones(&cfdns.ListZonesRequest{}), func(*cfdns.ListZonesResponseItem) error {
+		seen++
+		if seen == 10 {
+			return cfdns.ErrStopIteration
+		}
+
+		return nil
+	})
+	if err != nil || seen != 10 {
+		t.Errorf("Expected to stop after 10 items without error, got %d, %v", seen, err)
+	}
+
+	errTest := errors.New("test error")
+
+	err = cfdns.ForEach(ctx, client.ListZones(&cfdns.ListZonesRequest{}), func(*cfdns.ListZonesResponseItem) error {
+		return errTest
+	})
+	if !errors.Is(err, errTest) {
+		t.Errorf("Expected the error from the callback, got %v", err)
+	}
+}
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var requests atomic.Int32
+
+	client := newZonesClient(t, 1200, &requests)
+	it := client.ListZones(&cfdns.ListZonesRequest{})
+
+	n, err := cfdns.Count(ctx, it)
+	if err != nil || n != 1200 {
+		t.Errorf("Expected 1200 zones, got %d, %v", n, err)
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected only the first page to be fetched, got %d requests", n)
+	}
+
+	// the iterator can still be used
+	_, err = it.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err = cfdns.Count(ctx, it)
+	if err != nil || n != 1199 {
+		t.Errorf("Expected 1199 zones left, got %d, %v", n, err)
+	}
+
+	filtered := cfdns.Filter(it, func(z *cfdns.ListZonesResponseItem) bool {
+		return strings.HasSuffix(z.Name, "00")
+	})
+
+	n, err = cfdns.Count(ctx, filtered)
+	if err != nil || n != 11 {
+		t.Errorf("Expected 11 filtered zones, got %d, %v", n, err)
+	}
+}
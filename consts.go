@@ -7,6 +7,14 @@ import (
 const (
 	baseURL = "https://api.cloudflare.com/client/v4"
 
+	// packagePath is the import path of this package, used to find the
+	// code that called the client on the stack.
+	packagePath = "github.com/simplesurance/cfdns"
+
+	// cfRayHeader is the response header with the ID CloudFlare assigns to
+	// each request.
+	cfRayHeader = "Cf-Ray"
+
 	// defaultRequestInterval specifies the minimum interval between two
 	// requests sent to CloudFlare. Cloudflare by default limits clients to
 	// 1200 requests every 5 minutes. The default for the client is to
@@ -0,0 +1,142 @@
+package cfdns_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+	"github.com/simplesurance/cfdns/log"
+)
+
+func TestContextTags(t *testing.T) {
+	rec := &logRecorder{}
+
+	client := newTestClient(t, func(*http.Request) (*http.Response, error) {
+		resp := fakecf.JSONResponse(http.StatusServiceUnavailable,
+			`{"success":false,"errors":[{"code":10000,"message":"Service unavailable"}]}`)
+		resp.Header.Set("Cf-Ray", "8a1b2c3d4e5f-FRA")
+
+		return resp, nil
+	},
+		cfdns.WithLogger(log.New(rec, log.WithDebugEnabledFn(func() bool { return true }))),
+		cfdns.WithCircuitBreaker(cfdns.CircuitBreakerSettings{FailureThreshold: 1}))
+
+	ctx := log.ContextWithTags(context.Background(), log.WithString("request_id", "req-1"))
+
+	_, err := client.DeleteRecord(ctx, &cfdns.DeleteRecordRequest{
+		ZoneID:   "zone-id",
+		RecordID: "rec-id",
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if !strings.Contains(err.Error(), "request_id=req-1") {
+		t.Errorf("Error does not include context tags: %v", err)
+	}
+
+	var httpErr cfdns.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.RayID() != "8a1b2c3d4e5f-FRA" {
+		t.Errorf("Expected HTTPError with ray ID, got %v", err)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if len(rec.entries) == 0 {
+		t.Fatal("Nothing was logged")
+	}
+
+	sawRay, sawRetry, sawBreaker := false, false, false
+
+	for _, e := range rec.entries {
+		if e.Tags["request_id"] != "req-1" {
+			t.Errorf("Log entry %q does not have the context tags: %v", e.Message, e.Tags)
+		}
+
+		if e.Tags["cf-ray"] == "8a1b2c3d4e5f-FRA" {
+			sawRay = true
+		}
+
+		// entries logged by the retry logic have the cf-ray of the attempt
+		if strings.Contains(e.Message, "giving up") {
+			sawRetry = true
+
+			if e.Tags["cf-ray"] != "8a1b2c3d4e5f-FRA" {
+				t.Errorf("Retry log entry %q does not have the cf-ray tag: %v", e.Message, e.Tags)
+			}
+		}
+
+		if strings.Contains(e.Message, "Circuit breaker") {
+			sawBreaker = true
+		}
+	}
+
+	if !sawRay || !sawRetry || !sawBreaker {
+		t.Errorf("Expected entries with the cf-ray tag (%t), from retries (%t) and from the circuit breaker (%t)",
+			sawRay, sawRetry, sawBreaker)
+	}
+}
+
+func TestRetryLogCaller(t *testing.T) {
+	rec := &logRecorder{}
+
+	client := newTestClient(t, func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection reset by peer")
+	}, cfdns.WithLogger(log.New(rec, log.WithDebugEnabledFn(func() bool { return true }))))
+
+	// the context expires while waiting to retry
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.DeleteRecord(ctx, &cfdns.DeleteRecordRequest{ZoneID: "zone-id", RecordID: "rec-id"})
+	_, _, wantLine, _ := runtime.Caller(0)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the context to expire, got %v", err)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	severities := map[log.Severity]bool{}
+
+	for _, e := range rec.entries {
+		if !strings.Contains(e.Message, "f() returned an error") &&
+			!strings.Contains(e.Message, "context was canceled") {
+			continue
+		}
+
+		severities[e.Severity] = true
+
+		if !strings.HasSuffix(e.Caller.File, "contextlog_test.go") || e.Caller.Line != wantLine-1 {
+			t.Errorf("Expected caller of %q to be contextlog_test.go:%d, got %s:%d",
+				e.Message, wantLine-1, e.Caller.File, e.Caller.Line)
+		}
+	}
+
+	if !severities[log.Warn] || !severities[log.Debug] {
+		t.Errorf("Expected warning and debug entries from retries, got %v", severities)
+	}
+}
+
+type logRecorder struct {
+	mu      sync.Mutex
+	entries []*log.Entry
+}
+
+func (r *logRecorder) Send(e *log.Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, e)
+}
+
+func (r *logRecorder) PreLog() func() { return nil }
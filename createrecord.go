@@ -18,16 +18,14 @@ func (c *Client) CreateRecord(
 	ctx context.Context,
 	req *CreateRecordRequest,
 ) (*CreateRecordResponse, error) {
-	ttl := 1 // on CF the value "1" means "automatic"
-	if req.TTL > time.Second {
-		ttl = int(req.TTL.Seconds())
-	}
-
 	resp, err := sendRequestRetry[*createRecordAPIResponse](
 		ctx,
 		c,
 		c.logger.SubLogger(log.WithPrefix("CreateDNSRecord")),
 		&request{
+			operation:   "CreateDNSRecord",
+			zoneID:      req.ZoneID,
+			recordType:  req.Type,
 			method:      http.MethodPost,
 			path:        fmt.Sprintf("zones/%s/dns_records", url.PathEscape(req.ZoneID)),
 			queryParams: url.Values{},
@@ -38,21 +36,22 @@ func (c *Client) CreateRecord(
 				Proxied: req.Proxied,
 				Tags:    req.Tags,
 				Comment: req.Comment,
-				TTL:     ttl,
+				TTL:     apiTTL(req.TTL),
 			},
 		})
 	if err != nil {
 		return nil, err
 	}
 
-	c.logger.D(func(log log.DebugFn) {
+	c.contextLogger(ctx).D(func(log log.DebugFn) {
 		log(fmt.Sprintf("Record %s %s %s created with ID=%s",
 			req.Name, req.Type, req.Content, resp.body.Result.ID))
 	})
 
 	return &CreateRecordResponse{
-		ID:   resp.body.Result.ID,
-		Name: resp.body.Result.Name,
+		ID:       resp.body.Result.ID,
+		Name:     resp.body.Result.Name,
+		Messages: resp.body.Messages,
 	}, err
 }
 
@@ -70,6 +69,10 @@ type CreateRecordRequest struct {
 type CreateRecordResponse struct {
 	ID   string
 	Name string
+
+	// Messages are informative messages returned by CloudFlare, like
+	// warnings about the record.
+	Messages []CFMessage
 }
 
 type createRecordAPIRequest struct {
@@ -0,0 +1,226 @@
+// Package ddns keeps DNS records on CloudFlare updated with the public
+// address of the host, for hosts with dynamic IP addresses.
+//
+// The public IPv4 and IPv6 addresses are detected periodically with a
+// Detector, and the configured A and AAAA records are updated when the
+// address changes:
+//
+//	updater := ddns.New(client,
+//		ddns.WithIPv4(ddns.HTTPDetector("https://api.ipify.org", nil),
+//			ddns.Record{ZoneID: zoneID, Name: "home.example.com"}),
+//		ddns.WithOnChange(func(c ddns.Change) {
+//			fmt.Printf("%s is now %s\n", c.Record.Name, c.Current)
+//		}))
+//
+//	err := updater.Run(ctx)
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/log"
+)
+
+// Record is a DNS record kept updated with the public address. When the
+// address changes, all attributes of the record on CloudFlare are replaced,
+// so the comment and tags must be set to keep existing ones.
+type Record struct {
+	ZoneID  string
+	Name    string
+	Proxied bool
+	Comment string
+	Tags    []string
+
+	// TTL of the record. A TTL of 1 second or less will use CloudFlare's
+	// "automatic" TTL.
+	TTL time.Duration
+}
+
+// Change describes a record that was updated with a new address.
+type Change struct {
+	Record Record
+
+	// Type is the type of the record, "A" or "AAAA".
+	Type string
+
+	// Previous is the address detected on the previous update. It is
+	// invalid on the first update.
+	Previous netip.Addr
+	Current  netip.Addr
+
+	// Result tells if the record was created or updated.
+	Result cfdns.UpsertResult
+}
+
+// Updater periodically detects the public address and updates the
+// records.
+type Updater struct {
+	*settings
+
+	client *cfdns.Client
+
+	mu   sync.Mutex
+	last map[string]netip.Addr // last address applied, by record type
+}
+
+// New creates an updater. The records to be updated are configured with
+// WithIPv4 and WithIPv6.
+func New(client *cfdns.Client, opts ...Option) *Updater {
+	return &Updater{
+		settings: applyOptions(opts...),
+		client:   client,
+		last:     map[string]netip.Addr{},
+	}
+}
+
+// Run updates the records periodically until the context is done. After
+// a failure the update is retried with exponential backoff, instead of
+// waiting for the interval. It returns the error from the context.
+func (u *Updater) Run(ctx context.Context) error {
+	failures := 0
+
+	for {
+		err := u.Update(ctx)
+		if err != nil {
+			failures++
+
+			u.logger.W("Updating DNS records failed",
+				log.WithError(err),
+				log.WithInt("failures", failures))
+		} else {
+			failures = 0
+		}
+
+		timer := time.NewTimer(u.nextDelay(failures))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Update detects the addresses and updates the records if the addresses
+// changed since the last successful update. Each address family is
+// updated independently; errors from all are returned.
+func (u *Updater) Update(ctx context.Context) error {
+	var errs []error
+
+	for _, fam := range u.families {
+		err := u.updateFamily(ctx, fam)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s records: %w", fam.recordType, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (u *Updater) updateFamily(ctx context.Context, fam *family) error {
+	addr, err := fam.detector.Detect(ctx)
+	if err != nil {
+		return fmt.Errorf("detecting address: %w", err)
+	}
+
+	addr = addr.Unmap()
+	if !fam.valid(addr) {
+		return fmt.Errorf("detector returned %s, that is not valid for %s records",
+			addr, fam.recordType)
+	}
+
+	u.mu.Lock()
+	previous := u.last[fam.recordType]
+	u.mu.Unlock()
+
+	if addr == previous {
+		return nil
+	}
+
+	logger := u.logger.SubLogger(
+		log.WithString("address", addr.String()),
+		log.WithString("type", fam.recordType))
+	logger.I("Public address changed")
+
+	var errs []error
+
+	for _, rec := range fam.records {
+		resp, err := u.client.UpsertRecord(ctx, &cfdns.UpsertRecordRequest{
+			ZoneID:  rec.ZoneID,
+			Name:    rec.Name,
+			Type:    fam.recordType,
+			Content: addr.String(),
+			Proxied: rec.Proxied,
+			Comment: rec.Comment,
+			Tags:    rec.Tags,
+			TTL:     rec.TTL,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("updating %s: %w", rec.Name, err))
+			continue
+		}
+
+		logger.I("Record upserted",
+			log.WithString("record", rec.Name),
+			log.WithString("result", resp.Result.String()))
+
+		if u.onChange != nil && resp.Result != cfdns.UpsertUnchanged {
+			u.onChange(Change{
+				Record:   rec,
+				Type:     fam.recordType,
+				Previous: previous,
+				Current:  addr,
+				Result:   resp.Result,
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		// the address is not recorded, so all records are updated again
+		// on the next attempt; records that were already updated are not
+		// written again, because UpsertRecord skips them
+		return errors.Join(errs...)
+	}
+
+	u.mu.Lock()
+	u.last[fam.recordType] = addr
+	u.mu.Unlock()
+
+	return nil
+}
+
+// nextDelay returns how long to wait before the next update.
+func (u *Updater) nextDelay(failures int) time.Duration {
+	delay := u.interval
+
+	if failures > 0 {
+		delay = u.backoffMin
+		for i := 1; i < failures && delay < u.backoffMax; i++ {
+			delay *= 2
+		}
+
+		delay = min(delay, u.backoffMax)
+	}
+
+	if u.jitter > 0 {
+		// between -jitter and +jitter
+		delay += time.Duration((rand.Float64()*2 - 1) * u.jitter * float64(delay))
+	}
+
+	return delay
+}
+
+type family struct {
+	recordType string
+	detector   Detector
+	valid      func(netip.Addr) bool
+	records    []Record
+}
@@ -0,0 +1,183 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/ddns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+
+	addrs := []string{"192.0.2.1", "192.0.2.1", "198.51.100.7"}
+	calls := 0
+
+	detector := ddns.DetectorFunc(func(context.Context) (netip.Addr, error) {
+		addr := netip.MustParseAddr(addrs[calls])
+		calls++
+
+		return addr, nil
+	})
+
+	var changes []ddns.Change
+
+	updater := ddns.New(fake.Client(t),
+		ddns.WithIPv4(detector,
+			ddns.Record{ZoneID: zoneID, Name: "home.example.com"},
+			ddns.Record{ZoneID: zoneID, Name: "vpn.example.com", TTL: time.Minute}),
+		ddns.WithOnChange(func(c ddns.Change) { changes = append(changes, c) }))
+
+	for range addrs {
+		err := updater.Update(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := fake.Records(zoneID)
+	if len(records) != 2 || records[0].Content != "198.51.100.7" || records[1].Content != "198.51.100.7" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+
+	// the unchanged address must not be looked up on CloudFlare
+	if n := fake.Calls(http.MethodGet); n != 4 {
+		t.Errorf("Expected records to be looked up only when the address changes, got %d requests", n)
+	}
+
+	if len(changes) != 4 {
+		t.Fatalf("Expected 4 changes, got %d", len(changes))
+	}
+
+	last := changes[3]
+	if last.Type != "A" || last.Previous.String() != "192.0.2.1" ||
+		last.Current.String() != "198.51.100.7" || last.Result != cfdns.UpsertUpdated {
+		t.Errorf("Unexpected change: %+v", last)
+	}
+}
+
+func TestUpdateUnchangedRecord(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+	fake.AddRecord(zoneID, fakecf.Record{
+		Name:    "home.example.com",
+		Type:    "A",
+		Content: "192.0.2.1",
+		TTL:     1,
+		Tags:    []string{"owner:network", "env:home"},
+	})
+
+	var changes []ddns.Change
+
+	updater := ddns.New(fake.Client(t),
+		ddns.WithIPv4(fixedDetector("192.0.2.1"),
+			ddns.Record{ZoneID: zoneID, Name: "home.example.com", Tags: []string{"env:home", "owner:network"}},
+			ddns.Record{ZoneID: zoneID, Name: "vpn.example.com"}),
+		ddns.WithOnChange(func(c ddns.Change) { changes = append(changes, c) }))
+
+	err := updater.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the record that already had the address is not reported
+	if len(changes) != 1 || changes[0].Record.Name != "vpn.example.com" ||
+		changes[0].Result != cfdns.UpsertCreated {
+		t.Errorf("Expected only the created record to be reported, got %+v", changes)
+	}
+
+	if records := fake.Records(zoneID); len(records[0].Tags) != 2 {
+		t.Errorf("Expected the tags of the existing record to be kept, got %+v", records[0])
+	}
+}
+
+func TestUpdateWrongFamily(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+
+	updater := ddns.New(fake.Client(t),
+		ddns.WithIPv6(fixedDetector("192.0.2.1"), ddns.Record{ZoneID: zoneID, Name: "home.example.com"}))
+
+	err := updater.Update(context.Background())
+	if err == nil {
+		t.Error("Expected an error for an IPv4 address on AAAA records")
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+
+	var failures atomic.Int32
+
+	detector := ddns.DetectorFunc(func(context.Context) (netip.Addr, error) {
+		if failures.Add(1) <= 2 {
+			return netip.Addr{}, errors.New("network is down")
+		}
+
+		return netip.MustParseAddr("2001:db8::1"), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updater := ddns.New(fake.Client(t),
+		ddns.WithIPv6(detector, ddns.Record{ZoneID: zoneID, Name: "home.example.com"}),
+		ddns.WithInterval(time.Hour),
+		ddns.WithBackoff(time.Millisecond, 10*time.Millisecond),
+		ddns.WithJitter(0),
+		ddns.WithOnChange(func(ddns.Change) { cancel() }))
+
+	err := updater.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the updater to be stopped after the change, got %v", err)
+	}
+
+	records := fake.Records(zoneID)
+	if len(records) != 1 || records[0].Type != "AAAA" || records[0].Content != "2001:db8::1" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestHTTPDetector(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "198.51.100.7")
+	}))
+	defer srv.Close()
+
+	addr, err := ddns.HTTPDetector(srv.URL, srv.Client()).Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr.String() != "198.51.100.7" {
+		t.Errorf("Unexpected address %s", addr)
+	}
+}
+
+func fixedDetector(addr string) ddns.Detector {
+	return ddns.DetectorFunc(func(context.Context) (netip.Addr, error) {
+		return netip.MustParseAddr(addr), nil
+	})
+}
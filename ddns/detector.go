@@ -0,0 +1,123 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// maxEchoResponseLength is the maximum size of the response of an HTTP
+// echo service. It is enough for an IPv6 address with some whitespace.
+const maxEchoResponseLength = 128
+
+// Detector detects the current public address of the host.
+type Detector interface {
+	Detect(ctx context.Context) (netip.Addr, error)
+}
+
+// DetectorFunc allows using a function as a Detector.
+type DetectorFunc func(ctx context.Context) (netip.Addr, error)
+
+func (f DetectorFunc) Detect(ctx context.Context) (netip.Addr, error) {
+	return f(ctx)
+}
+
+// HTTPDetector detects the public address by sending a GET request to an
+// echo service that responds with the address of the client as plain
+// text, like https://api.ipify.org or https://api6.ipify.org. If client is
+// nil http.DefaultClient is used.
+//
+// The address family of the result depends on the network used to reach
+// the service, so a service that is reachable only over the desired family
+// must be used.
+func HTTPDetector(url string, client *http.Client) Detector {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return DetectorFunc(func(ctx context.Context) (netip.Addr, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		if resp.StatusCode != http.StatusOK {
+			return netip.Addr{}, fmt.Errorf("echo service %s returned HTTP %d", url, resp.StatusCode)
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxEchoResponseLength))
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		addr, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("echo service %s returned an invalid address: %w", url, err)
+		}
+
+		return addr.Unmap(), nil
+	})
+}
+
+// InterfaceIPv4 detects the address from the first global unicast IPv4
+// address of a local network interface. It is useful when the host is
+// directly connected to the internet.
+func InterfaceIPv4(name string) Detector {
+	return interfaceDetector(name, netip.Addr.Is4)
+}
+
+// InterfaceIPv6 detects the address from the first global unicast IPv6
+// address of a local network interface.
+func InterfaceIPv6(name string) Detector {
+	return interfaceDetector(name, netip.Addr.Is6)
+}
+
+func interfaceDetector(name string, family func(netip.Addr) bool) Detector {
+	return DetectorFunc(func(context.Context) (netip.Addr, error) {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		for _, a := range addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			addr, ok := netip.AddrFromSlice(ipnet.IP)
+			if !ok {
+				continue
+			}
+
+			addr = addr.Unmap()
+			if family(addr) && addr.IsGlobalUnicast() && !addr.IsPrivate() {
+				return addr, nil
+			}
+		}
+
+		return netip.Addr{}, fmt.Errorf("interface %s: %w", name, ErrNoAddress)
+	})
+}
+
+// ErrNoAddress is returned by detectors when no suitable address was found.
+var ErrNoAddress = errors.New("no public address found")
@@ -0,0 +1,130 @@
+package ddns
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/niltarget"
+)
+
+type Option func(*settings)
+
+const (
+	defaultInterval   = 5 * time.Minute
+	defaultJitter     = 0.1
+	defaultBackoffMin = 10 * time.Second
+	defaultBackoffMax = 5 * time.Minute
+)
+
+type settings struct {
+	families   []*family
+	interval   time.Duration
+	jitter     float64
+	backoffMin time.Duration
+	backoffMax time.Duration
+	onChange   func(Change)
+	logger     *log.Logger
+}
+
+func applyOptions(opts ...Option) *settings {
+	ret := settings{
+		interval:   defaultInterval,
+		jitter:     defaultJitter,
+		backoffMin: defaultBackoffMin,
+		backoffMax: defaultBackoffMax,
+		logger:     log.New(niltarget.New()), // by default log messages are suppressed
+	}
+	for _, opt := range opts {
+		opt(&ret)
+	}
+
+	// invalid values would make Run update without waiting
+	if ret.interval <= 0 {
+		ret.interval = defaultInterval
+	}
+
+	if ret.jitter < 0 || ret.jitter >= 1 {
+		ret.jitter = defaultJitter
+	}
+
+	if ret.backoffMin <= 0 {
+		ret.backoffMin = defaultBackoffMin
+	}
+
+	if ret.backoffMax < ret.backoffMin {
+		ret.backoffMax = ret.backoffMin
+	}
+
+	return &ret
+}
+
+// WithIPv4 configures A records to be updated with the IPv4 address
+// returned by the detector.
+func WithIPv4(detector Detector, records ...Record) Option {
+	return func(s *settings) {
+		s.families = append(s.families, &family{
+			recordType: "A",
+			detector:   detector,
+			valid:      netip.Addr.Is4,
+			records:    records,
+		})
+	}
+}
+
+// WithIPv6 configures AAAA records to be updated with the IPv6 address
+// returned by the detector.
+func WithIPv6(detector Detector, records ...Record) Option {
+	return func(s *settings) {
+		s.families = append(s.families, &family{
+			recordType: "AAAA",
+			detector:   detector,
+			valid:      netip.Addr.Is6,
+			records:    records,
+		})
+	}
+}
+
+// WithInterval configures how often the address is detected. The default
+// is 5 minutes, which is also used when interval is not positive.
+func WithInterval(interval time.Duration) Option {
+	return func(s *settings) {
+		s.interval = interval
+	}
+}
+
+// WithJitter randomizes the interval between updates by up to the
+// provided fraction of it, in both directions, so multiple hosts do not
+// send requests at the same time. The fraction must be at least 0 and
+// less than 1, otherwise the default of 0.1 (10%) is used.
+func WithJitter(fraction float64) Option {
+	return func(s *settings) {
+		s.jitter = fraction
+	}
+}
+
+// WithBackoff configures the delay before retrying after a failed update.
+// The delay starts at min and is doubled after each consecutive failure,
+// up to max. The default is 10 seconds up to 5 minutes. If min is not
+// positive the default is used, and if max is less than min, min is used.
+func WithBackoff(minDelay, maxDelay time.Duration) Option {
+	return func(s *settings) {
+		s.backoffMin = minDelay
+		s.backoffMax = maxDelay
+	}
+}
+
+// WithOnChange configures a function that is called for each record after
+// it is created or updated with a new address. It is not called for
+// records that already had the address on CloudFlare.
+func WithOnChange(fn func(Change)) Option {
+	return func(s *settings) {
+		s.onChange = fn
+	}
+}
+
+func WithLogger(logger *log.Logger) Option {
+	return func(s *settings) {
+		s.logger = logger
+	}
+}
@@ -0,0 +1,35 @@
+package ddns
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyOptionsInvalid(t *testing.T) {
+	t.Parallel()
+
+	s := applyOptions(
+		WithInterval(0),
+		WithJitter(1.5),
+		WithBackoff(-time.Second, -time.Minute))
+
+	if s.interval != defaultInterval {
+		t.Errorf("Expected interval to be %s, got %s", defaultInterval, s.interval)
+	}
+
+	if s.jitter != defaultJitter {
+		t.Errorf("Expected jitter to be %v, got %v", defaultJitter, s.jitter)
+	}
+
+	if s.backoffMin != defaultBackoffMin || s.backoffMax != defaultBackoffMin {
+		t.Errorf("Expected backoff to be %s up to %s, got %s up to %s",
+			defaultBackoffMin, defaultBackoffMin, s.backoffMin, s.backoffMax)
+	}
+
+	u := &Updater{settings: s}
+	for failures := range 10 {
+		if d := u.nextDelay(failures); d <= 0 {
+			t.Errorf("Expected a positive delay after %d failures, got %s", failures, d)
+		}
+	}
+}
@@ -16,12 +16,14 @@ func (c *Client) DeleteRecord(
 	ctx context.Context,
 	req *DeleteRecordRequest,
 ) (*DeleteRecordResponse, error) {
-	_, err := sendRequestRetry[*deleteRecordAPIResponse](
+	resp, err := sendRequestRetry[*deleteRecordAPIResponse](
 		ctx,
 		c,
 		c.logger.SubLogger(log.WithPrefix("DeleteDNSRecord")),
 		&request{
-			method: http.MethodDelete,
+			operation: "DeleteDNSRecord",
+			zoneID:    req.ZoneID,
+			method:    http.MethodDelete,
 			path: fmt.Sprintf("zones/%s/dns_records/%s",
 				url.PathEscape(req.ZoneID),
 				url.PathEscape(req.RecordID)),
@@ -32,11 +34,13 @@ func (c *Client) DeleteRecord(
 		return nil, err
 	}
 
-	c.logger.D(func(log log.DebugFn) {
+	c.contextLogger(ctx).D(func(log log.DebugFn) {
 		log(fmt.Sprintf("Record %s deleted", req.RecordID))
 	})
 
-	return &DeleteRecordResponse{}, err
+	return &DeleteRecordResponse{
+		Messages: resp.body.Messages,
+	}, err
 }
 
 type DeleteRecordRequest struct {
@@ -44,7 +48,10 @@ type DeleteRecordRequest struct {
 	RecordID string
 }
 
-type DeleteRecordResponse struct{}
+type DeleteRecordResponse struct {
+	// Messages are informative messages returned by CloudFlare.
+	Messages []CFMessage
+}
 
 type deleteRecordAPIResponse struct {
 	cfResponseCommon
@@ -0,0 +1,87 @@
+package cfdns
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// Sentinel errors for common failures. Errors returned by Client match them
+// with errors.Is, based on the CloudFlare error codes and on the HTTP status
+// of the response, so callers do not have to check error codes:
+//
+//	_, err := client.CreateRecord(ctx, req)
+//	if errors.Is(err, cfdns.ErrRecordAlreadyExists) {
+//		// ...
+//	}
+//
+// The full error can still be obtained with errors.As, using CloudFlareError
+// or HTTPError.
+var (
+	// ErrRecordAlreadyExists is returned when creating or updating a record
+	// would result in a duplicate record.
+	ErrRecordAlreadyExists = errors.New("DNS record already exists")
+
+	// ErrCNAMEConflict is returned when a CNAME record conflicts with
+	// another record with the same name, including when an identical CNAME
+	// record already exists. A CNAME record can not coexist with other
+	// records with the same name.
+	ErrCNAMEConflict = errors.New("DNS record conflicts with a CNAME record")
+
+	// ErrRecordNotFound is returned when the DNS record does not exist.
+	ErrRecordNotFound = errors.New("DNS record not found")
+
+	// ErrZoneNotFound is returned when the zone does not exist or is not
+	// accessible with the credentials used.
+	ErrZoneNotFound = errors.New("zone not found")
+
+	// ErrUnauthorized is returned when the credentials are invalid.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden is returned when the credentials are valid, but lack the
+	// permission for the operation.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrRateLimited is returned when CloudFlare rejected the request
+	// because the rate limit was exceeded.
+	ErrRateLimited = errors.New("rate limited by CloudFlare")
+
+	// ErrInvalidContent is returned when the content of a DNS record is not
+	// valid for its type, e.g., an A record that is not an IPv4 address.
+	ErrInvalidContent = errors.New("invalid DNS record content")
+)
+
+// cfErrorCodes maps sentinel errors to the CloudFlare error codes they
+// match. Code 81053 is returned when an A, AAAA or CNAME record conflicts
+// with a CNAME record, including an identical CNAME record; it only
+// matches ErrCNAMEConflict, since retrying or ignoring it as a duplicate
+// would not publish the record.
+var cfErrorCodes = map[error][]int{
+	ErrRecordAlreadyExists: {81057, 81058},
+	ErrCNAMEConflict:       {81053, 81054},
+	ErrRecordNotFound:      {81044},
+	ErrZoneNotFound:        {1001, 7003},
+	ErrUnauthorized:        {10000},
+	ErrRateLimited:         {971},
+	ErrInvalidContent:      {1004, 9005, 9006, 9007},
+}
+
+// httpStatuses maps sentinel errors to the HTTP status codes they match.
+var httpStatuses = map[error][]int{
+	ErrUnauthorized: {http.StatusUnauthorized},
+	ErrForbidden:    {http.StatusForbidden},
+	ErrRateLimited:  {http.StatusTooManyRequests},
+}
+
+// Is allows matching the error with the sentinel errors of this package,
+// based on the HTTP status code.
+func (e HTTPError) Is(target error) bool {
+	return slices.Contains(httpStatuses[target], e.Code)
+}
+
+// Is allows matching the error with the sentinel errors of this package,
+// based on the CloudFlare error codes. The HTTP status code is also
+// considered, because errors.Is also checks the wrapped HTTPError.
+func (ce CloudFlareError) Is(target error) bool {
+	return ce.IsAnyCFErrorCode(cfErrorCodes[target]...)
+}
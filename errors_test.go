@@ -0,0 +1,146 @@
+package cfdns_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		status int
+		code   int
+		want   []error
+	}{
+		{"duplicate", http.StatusBadRequest, 81058, []error{cfdns.ErrRecordAlreadyExists}},
+		{"duplicate CNAME", http.StatusBadRequest, 81053, []error{cfdns.ErrCNAMEConflict}},
+		{"CNAME conflict", http.StatusBadRequest, 81054, []error{cfdns.ErrCNAMEConflict}},
+		{"record not found", http.StatusNotFound, 81044, []error{cfdns.ErrRecordNotFound}},
+		{"zone not found", http.StatusNotFound, 7003, []error{cfdns.ErrZoneNotFound}},
+		{"unauthorized", http.StatusUnauthorized, 10000, []error{cfdns.ErrUnauthorized}},
+		{"forbidden", http.StatusForbidden, 9109, []error{cfdns.ErrForbidden}},
+		{"rate limited", http.StatusTooManyRequests, 971, []error{cfdns.ErrRateLimited}},
+		{"invalid content", http.StatusBadRequest, 9005, []error{cfdns.ErrInvalidContent}},
+	}
+
+	all := []error{
+		cfdns.ErrRecordAlreadyExists,
+		cfdns.ErrCNAMEConflict,
+		cfdns.ErrRecordNotFound,
+		cfdns.ErrZoneNotFound,
+		cfdns.ErrUnauthorized,
+		cfdns.ErrForbidden,
+		cfdns.ErrRateLimited,
+		cfdns.ErrInvalidContent,
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, func(*http.Request) (*http.Response, error) {
+				return fakecf.JSONResponse(tc.status, fmt.Sprintf(
+					`{"success":false,"errors":[{"code":%d,"message":"test error"}]}`, tc.code)), nil
+			})
+
+			_, err := client.DeleteRecord(context.Background(), &cfdns.DeleteRecordRequest{
+				ZoneID:   "zone-id",
+				RecordID: "rec-id",
+			})
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+
+			for _, sentinel := range all {
+				want := slices.Contains(tc.want, sentinel)
+				if have := errors.Is(err, sentinel); want != have {
+					t.Errorf("errors.Is(err, %q): want %t, have %t", sentinel, want, have)
+				}
+			}
+		})
+	}
+}
+
+func TestCloudFlareErrorDetails(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(*http.Request) (*http.Response, error) {
+		return fakecf.JSONResponse(http.StatusBadRequest, `{
+			"success": false,
+			"errors": [{
+				"code": 1004,
+				"message": "DNS Validation Error",
+				"documentation_url": "https://developers.cloudflare.com/dns/",
+				"error_chain": [{
+					"code": 9005,
+					"message": "Content for A record must be a valid IPv4 address.",
+					"error_chain": [{"code": 1, "message": "root cause"}]
+				}]
+			}],
+			"messages": [{"code": 2, "message": "some message"}]
+		}`), nil
+	})
+
+	_, err := client.DeleteRecord(context.Background(), &cfdns.DeleteRecordRequest{
+		ZoneID:   "zone-id",
+		RecordID: "rec-id",
+	})
+
+	var cfErr cfdns.CloudFlareError
+	if !errors.As(err, &cfErr) {
+		t.Fatalf("Expected cfdns.CloudFlareError, got %v", err)
+	}
+
+	if codes := cfErr.Codes(); !slices.Equal(codes, []int{1004}) {
+		t.Errorf("Unexpected codes: %v", codes)
+	}
+
+	if urls := cfErr.DocumentationURLs(); !slices.Equal(urls, []string{"https://developers.cloudflare.com/dns/"}) {
+		t.Errorf("Unexpected documentation URLs: %v", urls)
+	}
+
+	chain := cfErr.Details()[0].Chain()
+	if len(chain) != 2 || chain[0].Code != 9005 || chain[1].Code != 1 {
+		t.Errorf("Unexpected error chain: %v", chain)
+	}
+
+	if msgs := cfErr.ResponseMessages(); len(msgs) != 1 || msgs[0].String() != "2 some message" {
+		t.Errorf("Unexpected messages: %v", msgs)
+	}
+}
+
+func TestResponseMessages(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(*http.Request) (*http.Response, error) {
+		return fakecf.JSONResponse(http.StatusOK, `{
+			"success": true,
+			"result": {"id": "rec-id", "name": "rec.example.com"},
+			"messages": [{"code": 1000, "message": "This record is not proxied"}]
+		}`), nil
+	})
+
+	resp, err := client.CreateRecord(context.Background(), &cfdns.CreateRecordRequest{
+		ZoneID:  "zone-id",
+		Name:    "rec.example.com",
+		Type:    "A",
+		Content: "127.0.0.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []cfdns.CFMessage{{Code: 1000, Message: "This record is not proxied"}}
+	if !slices.Equal(resp.Messages, want) {
+		t.Errorf("Expected messages %v, got %v", want, resp.Messages)
+	}
+}
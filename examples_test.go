@@ -134,7 +134,7 @@ func ExampleCloudFlareError() {
 
 	fmt.Printf("Got HTTP error %v\n", cfErr.HTTPError.Code) // can also access response headers and raw response body
 
-	for _, cfe := range cfErr.Errors {
+	for _, cfe := range cfErr.Details() {
 		fmt.Printf("- CF error %d: %s\n", cfe.Code, cfe.Message) // can also access response headers and raw response body
 	}
 
@@ -0,0 +1,146 @@
+package cfdns
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Operation describes a logical operation executed by the client, like
+// creating a record or fetching one page of a list. One operation may
+// result in multiple attempts, if requests have to be retried.
+type Operation struct {
+	// Name identifies the operation, like "CreateDNSRecord" or
+	// "ListRecords".
+	Name string
+
+	// ZoneID is the zone the operation affects. It is empty for operations
+	// that are not related to a zone.
+	ZoneID string
+
+	// RecordType is the type of the DNS record the operation affects, or
+	// the type used to filter records when listing them. It is empty if
+	// not applicable.
+	RecordType string
+
+	// Method and Path identify the CloudFlare API endpoint. The path is
+	// relative to the API base URL.
+	Method string
+	Path   string
+
+	// The fields below are set when the operation finishes, before the
+	// innermost interceptor returns.
+
+	// Attempts is how many attempts were made.
+	Attempts int
+
+	// Latency is how long the operation took, including time spent
+	// waiting for the rate limiter and between retries.
+	Latency time.Duration
+
+	// Err is the final error returned by the operation.
+	Err error
+}
+
+// Attempt describes a single HTTP request sent to CloudFlare.
+type Attempt struct {
+	Operation *Operation
+
+	// Number is the number of the attempt, starting at 1.
+	Number int
+
+	// Request is the request that will be sent. Interceptors may modify
+	// it before calling next. Credentials are added after all interceptors
+	// were executed, so they are not visible here.
+	Request *http.Request
+
+	// LimiterWait is how long the attempt waited for the rate limiter
+	// before being sent.
+	LimiterWait time.Duration
+
+	// The fields below are set when the attempt finishes, before the
+	// innermost interceptor returns.
+
+	// Response is the response received from CloudFlare, or nil if no
+	// response was received. Its body was already consumed.
+	Response *http.Response
+
+	// ResponseSize is the size of the response body, in bytes.
+	ResponseSize int
+
+	// Latency is how long it took to send the request and read the
+	// response.
+	Latency time.Duration
+
+	// Err is the error of the attempt. If CloudFlare returned an error it
+	// can be obtained with errors.As, as with errors returned by Client.
+	Err error
+}
+
+// Interceptor allows executing code around operations and attempts made by
+// the client, e.g., for metrics, tracing or auditing. Both functions are
+// optional. Interceptors must call next to continue processing, and may
+// pass a modified context to it. The error returned by the interceptor is
+// the error of the operation or attempt.
+type Interceptor struct {
+	// Operation wraps each logical operation, including all its retries.
+	Operation func(ctx context.Context, op *Operation, next func(context.Context) error) error
+
+	// Attempt wraps each HTTP request sent to CloudFlare.
+	Attempt func(ctx context.Context, att *Attempt, next func(context.Context) error) error
+}
+
+// WithInterceptors adds interceptors to the client. Interceptors are
+// executed in the order they are provided, the first one being the
+// outermost. Can be used multiple times to add more interceptors.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(s *settings) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+func interceptOperation(
+	ctx context.Context,
+	interceptors []Interceptor,
+	op *Operation,
+	final func(context.Context) error,
+) error {
+	next := final
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		fn := interceptors[i].Operation
+		if fn == nil {
+			continue
+		}
+
+		inner := next
+		next = func(ctx context.Context) error {
+			return fn(ctx, op, inner)
+		}
+	}
+
+	return next(ctx)
+}
+
+func interceptAttempt(
+	ctx context.Context,
+	interceptors []Interceptor,
+	att *Attempt,
+	final func(context.Context) error,
+) error {
+	next := final
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		fn := interceptors[i].Attempt
+		if fn == nil {
+			continue
+		}
+
+		inner := next
+		next = func(ctx context.Context) error {
+			return fn(ctx, att, inner)
+		}
+	}
+
+	return next(ctx)
+}
@@ -0,0 +1,127 @@
+package cfdns_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
+
+	var calls []string
+
+	tracer := func(name string) cfdns.Interceptor {
+		return cfdns.Interceptor{
+			Operation: func(ctx context.Context, op *cfdns.Operation, next func(context.Context) error) error {
+				calls = append(calls, name+" op "+op.Name+" "+op.ZoneID)
+				err := next(ctx)
+				calls = append(calls, name+" op done")
+
+				if (op.Err == nil) != (err == nil) {
+					t.Errorf("Operation.Err is %v, next returned %v", op.Err, err)
+				}
+
+				return err
+			},
+			Attempt: func(ctx context.Context, att *cfdns.Attempt, next func(context.Context) error) error {
+				calls = append(calls, name+" attempt")
+
+				if att.Request.Header.Get("Authorization") != "" {
+					t.Error("Credentials must not be visible to interceptors")
+				}
+
+				att.Request.Header.Add("X-Interceptor", name)
+
+				return next(ctx)
+			},
+		}
+	}
+
+	var lastAttempt *cfdns.Attempt
+
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if have := req.Header.Values("X-Interceptor"); strings.Join(have, ",") != "outer,inner" {
+			t.Errorf("Request was not modified by interceptors: %v", have)
+		}
+
+		if req.Header.Get("Authorization") == "" {
+			t.Error("Request sent without credentials")
+		}
+
+		if strings.Contains(req.URL.Path, "bad-zone") {
+			return fakecf.JSONResponse(http.StatusBadRequest,
+				`{"success":false,"errors":[{"code":9005,"message":"Content for A record must be a valid IPv4 address."}]}`), nil
+		}
+
+		return fakecf.JSONResponse(http.StatusOK,
+			`{"success":true,"result":{"id":"rec-id","name":"rec.example.com"}}`), nil
+	},
+		cfdns.WithInterceptors(tracer("outer"), tracer("inner")),
+		cfdns.WithInterceptors(cfdns.Interceptor{
+			Attempt: func(ctx context.Context, att *cfdns.Attempt, next func(context.Context) error) error {
+				lastAttempt = att
+				return next(ctx)
+			},
+		}))
+
+	_, err := client.CreateRecord(ctx, &cfdns.CreateRecordRequest{
+		ZoneID:  "zone-id",
+		Name:    "rec.example.com",
+		Type:    "A",
+		Content: "1.1.1.1",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{
+		"outer op CreateDNSRecord zone-id",
+		"inner op CreateDNSRecord zone-id",
+		"outer attempt",
+		"inner attempt",
+		"inner op done",
+		"outer op done",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected interceptor calls:\nhave: %q\nwant: %q", calls, want)
+	}
+
+	if lastAttempt.Number != 1 || lastAttempt.Response.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected attempt %+v", lastAttempt)
+	}
+
+	// errors from CloudFlare are visible to the interceptor
+	_, err = client.CreateRecord(ctx, &cfdns.CreateRecordRequest{
+		ZoneID:  "bad-zone",
+		Name:    "rec.example.com",
+		Type:    "A",
+		Content: "github.com",
+	})
+
+	var cfErr cfdns.CloudFlareError
+	if !errors.As(lastAttempt.Err, &cfErr) || !cfErr.IsAnyCFErrorCode(9005) {
+		t.Errorf("Expected attempt to have CloudFlare error 9005, got %v", lastAttempt.Err)
+	}
+
+	if !errors.As(err, &cfErr) {
+		t.Errorf("Expected CloudFlareError, got %v", err)
+	}
+}
+
+// newTestClient creates a client that sends all requests to the provided
+// function, without rate limits.
+func newTestClient(
+	t *testing.T,
+	fn func(*http.Request) (*http.Response, error),
+	opts ...cfdns.Option,
+) *cfdns.Client {
+	t.Helper()
+
+	return fakecf.NewClient(t, fakecf.RoundTripperFunc(fn), opts...)
+}
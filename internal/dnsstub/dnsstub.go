@@ -0,0 +1,345 @@
+// Package dnsstub implements a minimal authoritative DNS server for tests.
+// It answers A, AAAA, CNAME and TXT queries over UDP and TCP from records
+// that can be changed while it is running.
+package dnsstub
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// Values used in DNS messages, from RFC 1035 and RFC 3596.
+const (
+	typeA     uint16 = 1
+	typeCNAME uint16 = 5
+	typeTXT   uint16 = 16
+	typeAAAA  uint16 = 28
+
+	classINET = 1
+
+	flagResponse      = 1 << 15
+	flagAuthoritative = 1 << 10
+	rcodeNameError    = 3
+
+	headerLength = 12
+
+	// questionNamePointer is a compressed name pointing to the name of the
+	// question, that follows the header.
+	questionNamePointer = 0xc000 | headerLength
+
+	maxLabelLength  = 63
+	maxStringLength = 255
+	ttl             = 60
+)
+
+var errInvalidMessage = errors.New("invalid DNS message")
+
+// Server is a DNS server listening on localhost.
+type Server struct {
+	udp net.PacketConn
+	tcp net.Listener
+
+	mu      sync.Mutex
+	records map[key][]string
+	queries int
+}
+
+type key struct {
+	name string
+	typ  uint16
+}
+
+type question struct {
+	name  string
+	typ   uint16
+	class uint16
+}
+
+// Start starts a server on a random port of localhost. The UDP and TCP
+// listeners use the same port.
+func Start() (*Server, error) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		_ = udp.Close()
+		return nil, err
+	}
+
+	s := &Server{
+		udp:     udp,
+		tcp:     tcp,
+		records: map[key][]string{},
+	}
+
+	go s.serveUDP()
+	go s.serveTCP()
+
+	return s, nil
+}
+
+// Addr returns the address of the server, as host:port.
+func (s *Server) Addr() string {
+	return s.udp.LocalAddr().String()
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	_ = s.udp.Close()
+	_ = s.tcp.Close()
+}
+
+// Set replaces the values of the records with the name and type. The type
+// must be "A", "AAAA", "CNAME" or "TXT". Setting no values removes the
+// records.
+func (s *Server) Set(name, typ string, values ...string) {
+	k := key{name: canonical(name), typ: parseType(typ)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(values) == 0 {
+		delete(s.records, k)
+		return
+	}
+
+	s.records[k] = values
+}
+
+// Queries returns how many queries were received.
+func (s *Server) Queries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.queries
+}
+
+func (s *Server) serveUDP() {
+	buf := make([]byte, 512)
+
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		resp, err := s.answer(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		_, _ = s.udp.WriteTo(resp, addr)
+	}
+}
+
+func (s *Server) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			var length uint16
+
+			err := binary.Read(conn, binary.BigEndian, &length)
+			if err != nil {
+				return
+			}
+
+			req := make([]byte, length)
+
+			_, err = io.ReadFull(conn, req)
+			if err != nil {
+				return
+			}
+
+			resp, err := s.answer(req)
+			if err != nil {
+				return
+			}
+
+			_ = binary.Write(conn, binary.BigEndian, uint16(len(resp)))
+			_, _ = conn.Write(resp)
+		}()
+	}
+}
+
+func (s *Server) answer(req []byte) ([]byte, error) {
+	id, q, err := parseQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	typ := q.typ
+	name := canonical(q.name)
+
+	s.mu.Lock()
+	s.queries++
+	values := s.records[key{name: name, typ: typ}]
+
+	cname := s.records[key{name: name, typ: typeCNAME}]
+	if len(values) == 0 && len(cname) > 0 {
+		values = cname
+		typ = typeCNAME
+	}
+	s.mu.Unlock()
+
+	flags := uint16(flagResponse | flagAuthoritative)
+	if len(values) == 0 {
+		flags |= rcodeNameError
+	}
+
+	resp := binary.BigEndian.AppendUint16(make([]byte, 0, 512), id)
+	resp = binary.BigEndian.AppendUint16(resp, flags)
+	resp = binary.BigEndian.AppendUint16(resp, 1)                   // questions
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(values))) // answers
+	resp = append(resp, 0, 0, 0, 0)                                 // authority and additional records
+
+	resp, err = appendName(resp, name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp = binary.BigEndian.AppendUint16(resp, q.typ)
+	resp = binary.BigEndian.AppendUint16(resp, q.class)
+
+	for _, v := range values {
+		data, err := resourceData(typ, v)
+		if err != nil {
+			return nil, err
+		}
+
+		resp = binary.BigEndian.AppendUint16(resp, questionNamePointer)
+		resp = binary.BigEndian.AppendUint16(resp, typ)
+		resp = binary.BigEndian.AppendUint16(resp, classINET)
+		resp = binary.BigEndian.AppendUint32(resp, ttl)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(data)))
+		resp = append(resp, data...)
+	}
+
+	return resp, nil
+}
+
+// parseQuery returns the ID and the question of a query. Other sections,
+// like the EDNS options sent by the Go resolver, are ignored.
+func parseQuery(req []byte) (uint16, question, error) {
+	if len(req) < headerLength || binary.BigEndian.Uint16(req[4:]) != 1 {
+		return 0, question{}, errInvalidMessage
+	}
+
+	var labels []string
+
+	off := headerLength
+
+	for {
+		if off >= len(req) {
+			return 0, question{}, errInvalidMessage
+		}
+
+		length := int(req[off])
+		off++
+
+		if length == 0 {
+			break
+		}
+
+		// queries do not use compression
+		if length > maxLabelLength || off+length > len(req) {
+			return 0, question{}, errInvalidMessage
+		}
+
+		labels = append(labels, string(req[off:off+length]))
+		off += length
+	}
+
+	if off+4 > len(req) {
+		return 0, question{}, errInvalidMessage
+	}
+
+	return binary.BigEndian.Uint16(req), question{
+		name:  strings.Join(labels, ".") + ".",
+		typ:   binary.BigEndian.Uint16(req[off:]),
+		class: binary.BigEndian.Uint16(req[off+2:]),
+	}, nil
+}
+
+// appendName appends a canonical name, without compression.
+func appendName(b []byte, name string) ([]byte, error) {
+	for label := range strings.SplitSeq(strings.TrimSuffix(name, "."), ".") {
+		if len(label) == 0 || len(label) > maxLabelLength {
+			return nil, errInvalidMessage
+		}
+
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	return append(b, 0), nil
+}
+
+func resourceData(typ uint16, value string) ([]byte, error) {
+	switch typ {
+	case typeA:
+		addr, err := netip.ParseAddr(value)
+		if err != nil || !addr.Is4() {
+			return nil, errors.New("invalid IPv4 address " + value)
+		}
+
+		return addr.AsSlice(), nil
+	case typeAAAA:
+		addr, err := netip.ParseAddr(value)
+		if err != nil || !addr.Is6() {
+			return nil, errors.New("invalid IPv6 address " + value)
+		}
+
+		return addr.AsSlice(), nil
+	case typeCNAME:
+		return appendName(nil, canonical(value))
+	case typeTXT:
+		// long values are split in multiple strings, that are joined by
+		// resolvers
+		var ret []byte
+
+		for len(value) > maxStringLength {
+			ret = append(append(ret, maxStringLength), value[:maxStringLength]...)
+			value = value[maxStringLength:]
+		}
+
+		return append(append(ret, byte(len(value))), value...), nil
+	default:
+		return nil, errors.New("unsupported record type")
+	}
+}
+
+func parseType(typ string) uint16 {
+	switch strings.ToUpper(typ) {
+	case "A":
+		return typeA
+	case "AAAA":
+		return typeAAAA
+	case "CNAME":
+		return typeCNAME
+	case "TXT":
+		return typeTXT
+	default:
+		panic("dnsstub: unsupported record type " + typ)
+	}
+}
+
+// canonical returns the name in lower case, with a trailing dot.
+func canonical(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+}
@@ -0,0 +1,59 @@
+package fakecf
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/time/rate"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/testtarget"
+)
+
+// RoundTripperFunc allows a function to be used as an http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Client creates a client that sends all requests to the fake.
+func (s *Server) Client(t *testing.T, opts ...cfdns.Option) *cfdns.Client {
+	t.Helper()
+
+	return NewClient(t, s, opts...)
+}
+
+// NewClient creates a client for tests that sends all requests to
+// transport, without rate limits. Log messages, including debug, are sent
+// to the test log. The options override those defaults.
+func NewClient(t *testing.T, transport http.RoundTripper, opts ...cfdns.Option) *cfdns.Client {
+	t.Helper()
+
+	creds, err := cfdns.APIToken("test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts = append([]cfdns.Option{
+		cfdns.WithHTTPClient(&http.Client{Transport: transport}),
+		cfdns.WithRateLimiter(rate.NewLimiter(rate.Inf, 1)),
+		cfdns.WithLogger(log.New(testtarget.ForTest(t, false),
+			log.WithDebugEnabledFn(func() bool { return true }))),
+	}, opts...)
+
+	return cfdns.NewClient(creds, opts...)
+}
+
+// JSONResponse creates a response with a JSON body, as returned by
+// CloudFlare.
+func JSONResponse(code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
@@ -0,0 +1,400 @@
+// Package fakecf implements an in-memory fake of the subset of the
+// CloudFlare API used by cfdns, to allow testing without a CloudFlare
+// account.
+//
+// The fake is an http.RoundTripper, so it can be used as the transport of
+// the HTTP client used by cfdns:
+//
+//	fake := fakecf.New()
+//	zoneID := fake.AddZone("example.com")
+//
+//	client := cfdns.NewClient(creds,
+//		cfdns.WithHTTPClient(&http.Client{Transport: fake}))
+//
+// Tests can create such a client, without rate limits, with Client.
+package fakecf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error codes returned by the fake, as returned by CloudFlare.
+const (
+	CodeCNAMEConflict  = 81053
+	CodeRecordExists   = 81058
+	CodeRecordNotFound = 81044
+	CodeZoneNotFound   = 7003
+	CodeInvalidRequest = 1004
+)
+
+const (
+	defaultPerPage      = 100
+	maxRecordsPerPage   = 5000000
+	maxZonesPerPage     = 50
+	apiPathPrefix       = "/client/v4"
+	recordIDPathPattern = apiPathPrefix + "/zones/{zone}/dns_records/{id}"
+)
+
+// Record is a DNS record stored on the fake.
+type Record struct {
+	ID      string   `json:"id"`
+	ZoneID  string   `json:"zone_id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Content string   `json:"content"`
+	Proxied bool     `json:"proxied"`
+	TTL     int      `json:"ttl"`
+	Comment string   `json:"comment"`
+	Tags    []string `json:"tags"`
+}
+
+// Zone is a zone stored on the fake.
+type Zone struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	NameServers []string `json:"name_servers"`
+}
+
+// Server is the fake CloudFlare API. It is safe for concurrent use.
+type Server struct {
+	mux *http.ServeMux
+
+	mu      sync.Mutex
+	zones   []*Zone
+	records map[string][]*Record // by zone ID
+	nextID  int
+	calls   map[string]int // by method
+}
+
+// New creates a fake without zones.
+func New() *Server {
+	s := &Server{
+		mux:     http.NewServeMux(),
+		records: map[string][]*Record{},
+		calls:   map[string]int{},
+	}
+
+	s.mux.HandleFunc("GET "+apiPathPrefix+"/zones", s.listZones)
+	s.mux.HandleFunc("GET "+apiPathPrefix+"/zones/{zone}", s.getZone)
+	s.mux.HandleFunc("GET "+apiPathPrefix+"/zones/{zone}/dns_records", s.listRecords)
+	s.mux.HandleFunc("POST "+apiPathPrefix+"/zones/{zone}/dns_records", s.createRecord)
+	s.mux.HandleFunc("PUT "+recordIDPathPattern, s.updateRecord)
+	s.mux.HandleFunc("DELETE "+recordIDPathPattern, s.deleteRecord)
+
+	return s
+}
+
+// RoundTrip handles the request with the fake, without sending it over
+// the network.
+func (s *Server) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	s.calls[req.Method]++
+	s.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, req)
+
+	return rec.Result(), nil
+}
+
+// AddZone adds a zone and returns its ID.
+func (s *Server) AddZone(name string, nameServers ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone := &Zone{
+		ID:          s.newID(),
+		Name:        name,
+		NameServers: nameServers,
+	}
+	s.zones = append(s.zones, zone)
+
+	return zone.ID
+}
+
+// AddRecord adds a record directly, without validation, and returns its ID.
+func (s *Server) AddRecord(zoneID string, rec Record) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec.ID = s.newID()
+	rec.ZoneID = zoneID
+	s.records[zoneID] = append(s.records[zoneID], &rec)
+
+	return rec.ID
+}
+
+// Records returns a copy of the records of a zone.
+func (s *Server) Records(zoneID string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]Record, len(s.records[zoneID]))
+	for i, rec := range s.records[zoneID] {
+		ret[i] = *rec
+	}
+
+	return ret
+}
+
+// Calls returns how many requests were received with the HTTP method.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%032x", s.nextID)
+}
+
+func (s *Server) listZones(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zones := s.zones
+	if name := req.URL.Query().Get("name"); name != "" {
+		zones = slices.DeleteFunc(slices.Clone(zones), func(z *Zone) bool {
+			return z.Name != name
+		})
+	}
+
+	writePage(w, req, zones, maxZonesPerPage)
+}
+
+func (s *Server) getZone(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := slices.IndexFunc(s.zones, func(z *Zone) bool {
+		return z.ID == req.PathValue("zone")
+	})
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, CodeZoneNotFound, "Could not route to zone")
+		return
+	}
+
+	writeResult(w, s.zones[idx])
+}
+
+func (s *Server) listRecords(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zoneID := req.PathValue("zone")
+	if !s.zoneExists(zoneID) {
+		writeError(w, http.StatusNotFound, CodeZoneNotFound, "Could not route to zone")
+		return
+	}
+
+	query := req.URL.Query()
+
+	var records []*Record
+
+	for _, rec := range s.records[zoneID] {
+		if name := query.Get("name"); name != "" && !strings.EqualFold(rec.Name, name) {
+			continue
+		}
+
+		if typ := query.Get("type"); typ != "" && rec.Type != typ {
+			continue
+		}
+
+		if content := query.Get("content"); content != "" && rec.Content != content {
+			continue
+		}
+
+		records = append(records, rec)
+	}
+
+	writePage(w, req, records, maxRecordsPerPage)
+}
+
+func (s *Server) createRecord(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zoneID := req.PathValue("zone")
+	if !s.zoneExists(zoneID) {
+		writeError(w, http.StatusNotFound, CodeZoneNotFound, "Could not route to zone")
+		return
+	}
+
+	var rec Record
+
+	err := json.NewDecoder(req.Body).Decode(&rec)
+	if err != nil || rec.Name == "" || rec.Type == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "DNS Validation Error")
+		return
+	}
+
+	if code, msg := s.conflict(zoneID, &rec); code != 0 {
+		writeError(w, http.StatusBadRequest, code, msg)
+		return
+	}
+
+	rec.ID = s.newID()
+	rec.ZoneID = zoneID
+	s.records[zoneID] = append(s.records[zoneID], &rec)
+
+	writeResult(w, &rec)
+}
+
+func (s *Server) updateRecord(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zoneID := req.PathValue("zone")
+
+	existing := s.findRecord(zoneID, req.PathValue("id"))
+	if existing == nil {
+		writeError(w, http.StatusNotFound, CodeRecordNotFound, "Record does not exist.")
+		return
+	}
+
+	var rec Record
+
+	err := json.NewDecoder(req.Body).Decode(&rec)
+	if err != nil || rec.Name == "" || rec.Type == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "DNS Validation Error")
+		return
+	}
+
+	rec.ID = existing.ID
+	rec.ZoneID = zoneID
+
+	if code, msg := s.conflict(zoneID, &rec); code != 0 {
+		writeError(w, http.StatusBadRequest, code, msg)
+		return
+	}
+
+	*existing = rec
+
+	writeResult(w, existing)
+}
+
+func (s *Server) deleteRecord(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zoneID := req.PathValue("zone")
+	id := req.PathValue("id")
+
+	if s.findRecord(zoneID, id) == nil {
+		writeError(w, http.StatusNotFound, CodeRecordNotFound, "Record does not exist.")
+		return
+	}
+
+	s.records[zoneID] = slices.DeleteFunc(s.records[zoneID], func(rec *Record) bool {
+		return rec.ID == id
+	})
+
+	writeResult(w, map[string]string{"id": id})
+}
+
+// conflict returns the error code if rec can not be stored, because it
+// duplicates another record or conflicts with a CNAME record.
+func (s *Server) conflict(zoneID string, rec *Record) (int, string) {
+	for _, other := range s.records[zoneID] {
+		if other.ID == rec.ID || !strings.EqualFold(other.Name, rec.Name) {
+			continue
+		}
+
+		if other.Type == "CNAME" || rec.Type == "CNAME" {
+			return CodeCNAMEConflict, "An A, AAAA, or CNAME record with that host already exists."
+		}
+
+		if other.Type == rec.Type && other.Content == rec.Content {
+			return CodeRecordExists, "An identical record already exists."
+		}
+	}
+
+	return 0, ""
+}
+
+func (s *Server) zoneExists(zoneID string) bool {
+	return slices.ContainsFunc(s.zones, func(z *Zone) bool {
+		return z.ID == zoneID
+	})
+}
+
+func (s *Server) findRecord(zoneID, id string) *Record {
+	for _, rec := range s.records[zoneID] {
+		if rec.ID == id {
+			return rec
+		}
+	}
+
+	return nil
+}
+
+func writePage[T any](w http.ResponseWriter, req *http.Request, items []T, maxPerPage int) {
+	query := req.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	page = max(page, 1)
+
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+
+	perPage = min(perPage, maxPerPage)
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success":  true,
+		"errors":   []any{},
+		"messages": []any{},
+		"result":   items[start:end],
+		"result_info": map[string]int{
+			"page":        page,
+			"per_page":    perPage,
+			"count":       end - start,
+			"total_count": len(items),
+		},
+	})
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success":  true,
+		"errors":   []any{},
+		"messages": []any{},
+		"result":   result,
+	})
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	writeJSON(w, status, map[string]any{
+		"success":  false,
+		"errors":   []any{map[string]any{"code": code, "message": msg}},
+		"messages": []any{},
+		"result":   nil,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+
+	err := json.NewEncoder(&buf).Encode(v)
+	if err != nil {
+		panic(err) // only happens in case of coding error on the fake
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -3,33 +3,182 @@ package cfdns
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"iter"
 )
 
 // Iterator implements an iterator algorithm from a function that fetches
 // blocks of data. This allows having fixed-memory usage when reading
 // arbitrary-sized structs without leaking implementation details about
 // how to paginate consecutive blocks of data.
+//
+// When prefetching is enabled with WithListPrefetch, pages are fetched in
+// the background while the current one is consumed. Background fetches are
+// canceled when the context passed to the last call to Next is done, or
+// when the iterator is closed with Close.
+//
+// The position of the iterator can be obtained with Cursor, and used to
+// create a new iterator that resumes from it, e.g., after an error.
 type Iterator[T any] struct {
-	fetchNext fetchFn[T]
-	elements  []*T
-	isLast    bool
+	fetchPage     fetchPageFn[T]
+	perPage       int
+	prefetch      int
+	detectChanges bool
+
+	elements   []*T
+	page       int // last page read
+	read       int // items read, including items not yet returned
+	skip       int // items to skip from the next page, when resuming
+	total      int
+	totalKnown bool
+	isLast     bool
+
+	// pages being fetched in background, in page order, with fetchCtx;
+	// fetchCtx is canceled when boundCtx, the context of the last call to
+	// Next, is done
+	pending     []*pageFetch[T]
+	fetchCtx    context.Context
+	cancelFetch context.CancelFunc
+	boundCtx    context.Context
+	stopWatch   func() bool
+
+	// derived iterators, created by Filter, Map and Take, read items from
+	// another iterator instead of fetching pages
+	next   func(ctx context.Context) (*T, error)
+	source *derivedSource
+}
+
+type derivedSource struct {
+	cursor func() Cursor
+	close  func()
+}
+
+// fetchPageFn fetches one page of data. Pages start at 1.
+type fetchPageFn[T any] func(ctx context.Context, page int) (*fetchedPage[T], error)
+
+type fetchedPage[T any] struct {
+	items      []*T
+	perPage    int // page size reported by the server, if any
+	totalCount int
+}
+
+type pageFetch[T any] struct {
+	done   chan struct{}
+	result *fetchedPage[T]
+	err    error
+}
+
+// newFailedIterator returns an iterator that fails with err.
+func newFailedIterator[T any](err error) *Iterator[T] {
+	return &Iterator[T]{
+		next: func(context.Context) (*T, error) {
+			return nil, err
+		},
+	}
+}
+
+func newIterator[T any](perPage, prefetch int, fetchPage fetchPageFn[T]) *Iterator[T] {
+	return &Iterator[T]{
+		fetchPage: fetchPage,
+		perPage:   perPage,
+		prefetch:  prefetch,
+	}
+}
+
+// Cursor is the position of an iterator. It can be serialized, e.g., as
+// JSON, and used to resume iterating from the same position with a new
+// iterator.
+type Cursor struct {
+	// Page is the last page that was fetched.
+	Page int `json:"page"`
+
+	// PerPage is how many items were requested per page.
+	PerPage int `json:"per_page"`
+
+	// Read is how many items were returned by the iterator.
+	Read int `json:"read"`
+
+	// TotalCount is the amount of items reported by CloudFlare on the last
+	// page fetched, or 0 if no page was fetched.
+	TotalCount int `json:"total_count"`
+}
+
+// ErrListChanged is matched by errors.Is on errors returned by iterators
+// when change detection is enabled and the list changed while being read.
+var ErrListChanged = errors.New("list changed while being read")
+
+// ListChangedError is returned by iterators with change detection enabled
+// when the total amount of items reported by CloudFlare changes between
+// pages. Items were added or removed while the list was being read, so
+// items may be returned twice or skipped. The error is reported once, and
+// the iterator can be used to continue reading the list.
+type ListChangedError struct {
+	Cursor        Cursor
+	PreviousTotal int
+	Total         int
+}
+
+func (e ListChangedError) Error() string {
+	return fmt.Sprintf("%v: total count changed from %d to %d after reading %d items; items may be duplicated or missing",
+		ErrListChanged, e.PreviousTotal, e.Total, e.Cursor.Read)
+}
+
+func (e ListChangedError) Is(target error) bool {
+	return target == ErrListChanged
+}
+
+var _ error = ListChangedError{}
+
+// Cursor returns the current position of the iterator. The next item
+// returned by the iterator is the one after the position. Iterators
+// created with Filter, Map and Take return the position of the iterator
+// they read from.
+func (it *Iterator[T]) Cursor() Cursor {
+	if it.source != nil {
+		return it.source.cursor()
+	}
+
+	ret := Cursor{
+		Page:    it.page,
+		PerPage: it.perPage,
+		Read:    it.read + it.skip - len(it.elements),
+	}
+
+	if it.totalKnown {
+		ret.TotalCount = it.total
+	}
+
+	return ret
 }
 
-type fetchFn[T any] func(ctx context.Context) (batch []*T, last bool, _ error)
+// resume makes the iterator start reading after the position of the
+// cursor. Only the amount of items read is used to determine the position,
+// so the page size may differ from the one used to obtain the cursor. The
+// items to skip from the first page are counted as read by Cursor until
+// the page is fetched.
+func (it *Iterator[T]) resume(cursor Cursor) {
+	it.page = cursor.Read / it.perPage
+	it.read = it.page * it.perPage
+	it.skip = cursor.Read % it.perPage
+
+	if cursor.TotalCount > 0 {
+		it.total = cursor.TotalCount
+		it.totalKnown = true
+		it.isLast = cursor.Read >= cursor.TotalCount
+	}
+}
 
 // Next fetches the next item. If there are no more records io.EOF will be
 // returned.
 func (it *Iterator[T]) Next(ctx context.Context) (retElm *T, err error) {
-	if len(it.elements) == 0 && !it.isLast {
-		var elements []*T
-
-		elements, it.isLast, err = it.fetchNext(ctx)
-		if err != nil {
-			return nil, err
-		}
+	if it.next != nil {
+		return it.next(ctx)
+	}
 
-		it.elements = elements
+	err = it.fill(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(it.elements) == 0 {
@@ -42,6 +191,201 @@ func (it *Iterator[T]) Next(ctx context.Context) (retElm *T, err error) {
 	return retElm, nil
 }
 
+// fill fetches the next page if all items from the current one were
+// returned.
+func (it *Iterator[T]) fill(ctx context.Context) error {
+	if len(it.elements) == 0 && !it.isLast {
+		page, err := it.nextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		if it.detectChanges && it.totalKnown && page.totalCount != it.total {
+			err := ListChangedError{
+				Cursor:        it.Cursor(),
+				PreviousTotal: it.total,
+				Total:         page.totalCount,
+			}
+
+			// the page is discarded; if the list does not change
+			// again, it is fetched again on the next call without error
+			it.total = page.totalCount
+			it.Close()
+
+			return err
+		}
+
+		if page.perPage > 0 {
+			it.perPage = page.perPage
+		}
+
+		it.page++
+		it.read += len(page.items)
+		it.total = page.totalCount
+		it.totalKnown = true
+		it.isLast = it.read >= it.total || len(page.items) == 0
+		it.elements = page.items[min(it.skip, len(page.items)):]
+		it.skip = 0
+
+		if it.isLast {
+			it.Close()
+		} else {
+			it.schedule(ctx)
+		}
+	}
+
+	return nil
+}
+
+// Close stops fetching pages in the background. It is only necessary when
+// prefetching is enabled, the iterator is not read until the end and the
+// context passed to Next is not done. The iterator can still be used after
+// being closed.
+func (it *Iterator[T]) Close() {
+	if it.source != nil {
+		it.source.close()
+		return
+	}
+
+	if it.cancelFetch != nil {
+		it.stopWatch()
+		it.cancelFetch()
+	}
+
+	it.pending = nil
+	it.fetchCtx = nil
+	it.cancelFetch = nil
+	it.boundCtx = nil
+	it.stopWatch = nil
+}
+
+// bind makes the background fetches be canceled when ctx is done. Fetches
+// started by previous calls to Next are kept while ctx is not done, unless
+// they were canceled because the context of a previous call was done.
+func (it *Iterator[T]) bind(ctx context.Context) {
+	if it.fetchCtx != nil && it.fetchCtx.Err() != nil {
+		it.Close()
+	}
+
+	if it.fetchCtx == nil {
+		// values from the context, like log tags, are kept
+		it.fetchCtx, it.cancelFetch = context.WithCancel(context.WithoutCancel(ctx))
+	}
+
+	if ctx != it.boundCtx {
+		if it.stopWatch != nil {
+			it.stopWatch()
+		}
+
+		it.boundCtx = ctx
+		it.stopWatch = context.AfterFunc(ctx, it.cancelFetch)
+	}
+}
+
+// nextPage returns the page after the last one read, waiting for it to be
+// fetched in the background when prefetching is enabled.
+func (it *Iterator[T]) nextPage(ctx context.Context) (*fetchedPage[T], error) {
+	if it.prefetch <= 0 {
+		return it.fetchPage(ctx, it.page+1)
+	}
+
+	it.schedule(ctx)
+
+	if len(it.pending) == 0 {
+		// all pages were read
+		it.isLast = true
+		return nil, io.EOF
+	}
+
+	f := it.pending[0]
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	it.pending = it.pending[1:]
+
+	if f.err != nil {
+		// pages after the failed one are fetched again on the next call
+		it.Close()
+		return nil, f.err
+	}
+
+	return f.result, nil
+}
+
+// schedule starts fetching the pages after the last one read, in the
+// background, until prefetch pages are pending. Until the first page is
+// read the amount of pages may be unknown, so only one is fetched.
+func (it *Iterator[T]) schedule(ctx context.Context) {
+	if it.prefetch <= 0 || it.isLast {
+		return
+	}
+
+	it.bind(ctx)
+
+	for len(it.pending) < it.prefetch {
+		if !it.totalKnown && len(it.pending) > 0 {
+			return
+		}
+
+		page := it.page + len(it.pending) + 1
+		if it.totalKnown && (page-1)*it.perPage >= it.total {
+			return
+		}
+
+		fetchCtx := it.fetchCtx
+
+		f := &pageFetch[T]{
+			done: make(chan struct{}),
+		}
+
+		go func() {
+			defer close(f.done)
+
+			f.result, f.err = it.fetchPage(fetchCtx, page)
+		}()
+
+		it.pending = append(it.pending, f)
+	}
+}
+
+// All returns an iterator over the remaining elements, to be used with
+// range. Elements are still fetched one page at a time, and no more pages
+// are fetched after the loop is interrupted; pages being prefetched are
+// canceled. If an error happens it is yielded once with a nil element,
+// and the iteration ends.
+//
+//	for rec, err := range client.ListRecords(req).All(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+func (it *Iterator[T]) All(ctx context.Context) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		defer it.Close()
+
+		for {
+			item, err := it.Next(ctx)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
 // ReadAll is an utility function that reads all elements from an iterator
 // and return them as an array.
 func ReadAll[T any](ctx context.Context, it *Iterator[T]) ([]*T, error) {
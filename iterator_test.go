@@ -0,0 +1,414 @@
+package cfdns_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestIteratorAll(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	// 3 pages with 2 zones each
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+
+		return fakecf.JSONResponse(http.StatusOK, fmt.Sprintf(`{
+			"success": true,
+			"result": [{"id": "%[1]d-1", "name": "zone%[1]d-1"}, {"id": "%[1]d-2", "name": "zone%[1]d-2"}],
+			"result_info": {"page": %[1]d, "per_page": 2, "count": 2, "total_count": 6}
+		}`, page)), nil
+	})
+
+	ctx := context.Background()
+
+	var names []string
+
+	for zone, err := range client.ListZones(&cfdns.ListZonesRequest{}).All(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, zone.Name)
+	}
+
+	if len(names) != 6 || names[5] != "zone3-2" {
+		t.Errorf("Unexpected zones: %v", names)
+	}
+
+	// breaking the loop must stop fetching pages
+	requests.Store(0)
+
+	for range client.ListZones(&cfdns.ListZonesRequest{}).All(ctx) {
+		break
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected 1 request after breaking the loop, got %d", n)
+	}
+}
+
+func TestIteratorAllError(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(*http.Request) (*http.Response, error) {
+		return fakecf.JSONResponse(http.StatusForbidden,
+			`{"success":false,"errors":[{"code":9109,"message":"Unauthorized to access requested resource"}]}`), nil
+	})
+
+	errs := 0
+
+	for zone, err := range client.ListZones(&cfdns.ListZonesRequest{}).All(context.Background()) {
+		if zone != nil || err == nil {
+			t.Errorf("Expected only an error, got %v, %v", zone, err)
+		}
+
+		errs++
+	}
+
+	if errs != 1 {
+		t.Errorf("Expected the error to be yielded once, got %d", errs)
+	}
+}
+
+func TestIteratorPrefetch(t *testing.T) {
+	t.Parallel()
+
+	const pages = 5
+
+	var (
+		requests    atomic.Int32
+		concurrent  atomic.Int32
+		maxParallel atomic.Int32
+	)
+
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+
+		n := concurrent.Add(1)
+		defer concurrent.Add(-1)
+
+		for {
+			highest := maxParallel.Load()
+			if n <= highest || maxParallel.CompareAndSwap(highest, n) {
+				break
+			}
+		}
+
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+
+		return zonesPage(page, perPage, pages*perPage), nil
+	}, cfdns.WithListPrefetch(3))
+
+	var (
+		read     int
+		lastName string
+	)
+
+	for zone, err := range client.ListZones(&cfdns.ListZonesRequest{}).All(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if zone.Name <= lastName {
+			t.Fatalf("Zones out of order: %s after %s", zone.Name, lastName)
+		}
+
+		lastName = zone.Name
+		read++
+	}
+
+	if read != pages*50 {
+		t.Errorf("Expected %d zones, got %d", pages*50, read)
+	}
+
+	if n := requests.Load(); n != pages {
+		t.Errorf("Expected %d requests, got %d", pages, n)
+	}
+
+	if n := maxParallel.Load(); n > 3 {
+		t.Errorf("Expected at most 3 concurrent requests, got %d", n)
+	}
+}
+
+func TestIteratorPrefetchStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests atomic.Int32
+		started  = make(chan struct{}, 2)
+		canceled = make(chan struct{}, 2)
+	)
+
+	// pages after the first never complete, until their request is
+	// canceled
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+
+		if page > 1 {
+			started <- struct{}{}
+
+			<-req.Context().Done()
+			canceled <- struct{}{}
+
+			return nil, req.Context().Err()
+		}
+
+		return zonesPage(page, perPage, 5*perPage), nil
+	}, cfdns.WithListPrefetch(2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	it := client.ListZones(&cfdns.ListZonesRequest{})
+
+	_, err := it.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitAll := func(ch chan struct{}, what string) {
+		for range 2 {
+			select {
+			case <-ch:
+			case <-time.After(5 * time.Second):
+				t.Fatal(what)
+			}
+		}
+	}
+
+	waitAll(started, "Pages were not prefetched")
+
+	// the iterator is abandoned without calling Close
+	cancel()
+
+	waitAll(canceled, "Background fetches were not canceled with the context")
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the first page and the 2 prefetched ones
+	if n := requests.Load(); n != 3 {
+		t.Errorf("Expected no requests after the context was canceled, got %d requests", n)
+	}
+}
+
+// zonesPage returns a page of a list of zones with sortable names.
+func zonesPage(page, perPage, total int) *http.Response {
+	var items []string
+
+	for i := (page - 1) * perPage; i < min(page*perPage, total); i++ {
+		items = append(items, fmt.Sprintf(`{"id": "%[1]d", "name": "zone%08[1]d"}`, i))
+	}
+
+	return fakecf.JSONResponse(http.StatusOK, fmt.Sprintf(`{
+		"success": true,
+		"result": [%s],
+		"result_info": {"page": %d, "per_page": %d, "count": %d, "total_count": %d}
+	}`, strings.Join(items, ","), page, perPage, len(items), total))
+}
+
+func TestIteratorResume(t *testing.T) {
+	t.Parallel()
+
+	const total = 1800
+
+	var failed atomic.Bool
+
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+
+		if page == 3 && failed.CompareAndSwap(false, true) {
+			return fakecf.JSONResponse(http.StatusBadRequest,
+				`{"success":false,"errors":[{"code":1000,"message":"test error"}]}`), nil
+		}
+
+		return zonesPage(page, perPage, total), nil
+	})
+
+	ctx := context.Background()
+	it := client.ListZones(&cfdns.ListZonesRequest{})
+
+	var read int
+
+	for _, err := range it.All(ctx) {
+		if err != nil {
+			break
+		}
+
+		read++
+	}
+
+	cursor := it.Cursor()
+	if read != 100 || cursor.Read != 100 || cursor.Page != 2 || cursor.TotalCount != total {
+		t.Fatalf("Unexpected position after error: read=%d cursor=%+v", read, cursor)
+	}
+
+	// resume from the middle of a page
+	it = client.ListZones(&cfdns.ListZonesRequest{Cursor: &cfdns.Cursor{Read: 125}})
+
+	zone, err := it.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if zone.Name != "zone00000125" {
+		t.Errorf("Expected to resume at zone00000125, got %s", zone.Name)
+	}
+
+	rest, err := cfdns.ReadAll(ctx, it)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rest) != total-126 {
+		t.Errorf("Expected %d more zones, got %d", total-126, len(rest))
+	}
+}
+
+func TestIteratorResumeExhausted(t *testing.T) {
+	t.Parallel()
+
+	for _, prefetch := range []int{0, 2} {
+		t.Run(fmt.Sprintf("prefetch=%d", prefetch), func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+				perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+
+				return zonesPage(page, perPage, 10), nil
+			}, cfdns.WithListPrefetch(prefetch))
+
+			// the cursor was saved after the last zone
+			cursor := cfdns.Cursor{Page: 2, PerPage: 5, Read: 10, TotalCount: 10}
+			it := client.ListZones(&cfdns.ListZonesRequest{PerPage: 5, Cursor: &cursor})
+
+			zone, err := it.Next(context.Background())
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Expected io.EOF, got %v, %v", zone, err)
+			}
+
+			if got := it.Cursor(); got != cursor {
+				t.Errorf("Expected cursor %+v, got %+v", cursor, got)
+			}
+		})
+	}
+}
+
+func TestIteratorResumeFailedFetch(t *testing.T) {
+	t.Parallel()
+
+	var failed atomic.Bool
+
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if failed.CompareAndSwap(false, true) {
+			return fakecf.JSONResponse(http.StatusForbidden,
+				`{"success":false,"errors":[{"code":9109,"message":"Unauthorized to access requested resource"}]}`), nil
+		}
+
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+
+		return zonesPage(page, perPage, 10), nil
+	})
+
+	ctx := context.Background()
+	it := client.ListZones(&cfdns.ListZonesRequest{PerPage: 5, Cursor: &cfdns.Cursor{Read: 7, PerPage: 5}})
+
+	_, err := it.Next(ctx)
+	if err == nil {
+		t.Fatal("Expected the first fetch to fail")
+	}
+
+	// the position is kept in the middle of the page
+	cursor := it.Cursor()
+	if cursor.Read != 7 {
+		t.Fatalf("Expected cursor to be at 7 items after the error, got %+v", cursor)
+	}
+
+	zones, err := cfdns.ReadAll(ctx, client.ListZones(&cfdns.ListZonesRequest{PerPage: 5, Cursor: &cursor}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(zones) != 3 || zones[0].Name != "zone00000007" {
+		t.Errorf("Expected to resume at zone00000007, got %d zones", len(zones))
+	}
+}
+
+func TestIteratorDetectChanges(t *testing.T) {
+	t.Parallel()
+
+	var total atomic.Int32
+	total.Store(1200)
+
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+
+		if page == 2 {
+			// a zone is added while the list is being read
+			total.CompareAndSwap(1200, 1201)
+		}
+
+		return zonesPage(page, perPage, int(total.Load())), nil
+	})
+
+	ctx := context.Background()
+	it := client.ListZones(&cfdns.ListZonesRequest{DetectChanges: true})
+
+	var (
+		read    int
+		changed []error
+	)
+
+	for {
+		_, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			if !errors.Is(err, cfdns.ErrListChanged) {
+				t.Fatal(err)
+			}
+
+			changed = append(changed, err)
+
+			continue
+		}
+
+		read++
+	}
+
+	var changeErr cfdns.ListChangedError
+	if len(changed) != 1 || !errors.As(changed[0], &changeErr) {
+		t.Fatalf("Expected one ListChangedError, got %v", changed)
+	}
+
+	if changeErr.PreviousTotal != 1200 || changeErr.Total != 1201 || changeErr.Cursor.Read != 50 {
+		t.Errorf("Unexpected error: %+v", changeErr)
+	}
+
+	if read != 1201 {
+		t.Errorf("Expected to continue reading after the error, read %d zones", read)
+	}
+}
@@ -0,0 +1,70 @@
+package cfdns
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Limits of the page size. CloudFlare accepts larger pages of records, but
+// responses with more than MaxRecordsPerPage records are not read by the
+// client, to limit the memory used.
+const (
+	MinPerPage        = 5
+	MaxRecordsPerPage = 5000
+	MaxZonesPerPage   = 50
+)
+
+// Direction is the direction in which lists are sorted.
+type Direction string
+
+const (
+	Ascending  Direction = "asc"
+	Descending Direction = "desc"
+)
+
+// RecordOrder is the field used to sort DNS records.
+type RecordOrder string
+
+const (
+	RecordOrderName    RecordOrder = "name"
+	RecordOrderType    RecordOrder = "type"
+	RecordOrderContent RecordOrder = "content"
+	RecordOrderTTL     RecordOrder = "ttl"
+	RecordOrderProxied RecordOrder = "proxied"
+)
+
+// ZoneOrder is the field used to sort zones.
+type ZoneOrder string
+
+const (
+	ZoneOrderName        ZoneOrder = "name"
+	ZoneOrderStatus      ZoneOrder = "status"
+	ZoneOrderAccountID   ZoneOrder = "account.id"
+	ZoneOrderAccountName ZoneOrder = "account.name"
+)
+
+func validatePerPage(perPage, maxPerPage int) error {
+	if perPage != 0 && (perPage < MinPerPage || perPage > maxPerPage) {
+		return fmt.Errorf("invalid page size %d: must be between %d and %d",
+			perPage, MinPerPage, maxPerPage)
+	}
+
+	return nil
+}
+
+func validateDirection(direction Direction) error {
+	if direction != "" && direction != Ascending && direction != Descending {
+		return fmt.Errorf("invalid direction %q: must be %q or %q",
+			direction, Ascending, Descending)
+	}
+
+	return nil
+}
+
+func validateOrder[T ~string](order T, valid ...T) error {
+	if order != "" && !slices.Contains(valid, order) {
+		return fmt.Errorf("invalid order %q: must be one of %q", order, valid)
+	}
+
+	return nil
+}
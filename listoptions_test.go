@@ -0,0 +1,106 @@
+package cfdns_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestListRecordsOrder(t *testing.T) {
+	t.Parallel()
+
+	var query url.Values
+
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		query = req.URL.Query()
+		return fakecf.JSONResponse(http.StatusOK, `{"success":true,"result":[],"result_info":{"total_count":0}}`), nil
+	})
+
+	_, err := cfdns.ReadAll(context.Background(), client.ListRecords(&cfdns.ListRecordsRequest{
+		ZoneID:    "zone-id",
+		PerPage:   20,
+		Order:     cfdns.RecordOrderName,
+		Direction: cfdns.Descending,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k, want := range map[string]string{
+		"per_page":  "20",
+		"order":     "name",
+		"direction": "desc",
+	} {
+		if have := query.Get(k); have != want {
+			t.Errorf("Query parameter %s: want %q, have %q", k, want, have)
+		}
+	}
+}
+
+func TestListValidation(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(*http.Request) (*http.Response, error) {
+		t.Error("No request must be sent for invalid list requests")
+		return nil, http.ErrAbortHandler
+	})
+
+	ctx := context.Background()
+
+	for name, req := range map[string]*cfdns.ListRecordsRequest{
+		"nil request":    nil,
+		"page too small": {ZoneID: "zone-id", PerPage: 1},
+		"page too large": {ZoneID: "zone-id", PerPage: cfdns.MaxRecordsPerPage + 1},
+		"invalid order":  {ZoneID: "zone-id", Order: "modified_on"},
+		"invalid dir":    {ZoneID: "zone-id", Direction: "up"},
+	} {
+		_, err := client.ListRecords(req).Next(ctx)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	_, err := client.ListZones(&cfdns.ListZonesRequest{PerPage: 100}).Next(ctx)
+	if err == nil {
+		t.Error("Expected an error for a page size above the limit for zones")
+	}
+}
+
+func TestListRecordsMaxPerPage(t *testing.T) {
+	t.Parallel()
+
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+
+	// the page is larger than responses allowed for the default page size
+	comment := strings.Repeat("c", 250)
+	for i := range cfdns.MaxRecordsPerPage {
+		fake.AddRecord(zoneID, fakecf.Record{
+			Name:    fmt.Sprintf("host%d.example.com", i),
+			Type:    "A",
+			Content: "192.0.2.1",
+			Comment: comment,
+		})
+	}
+
+	it := fake.Client(t).ListRecords(&cfdns.ListRecordsRequest{
+		ZoneID:  zoneID,
+		PerPage: cfdns.MaxRecordsPerPage,
+	})
+
+	records, err := cfdns.ReadAll(context.Background(), it)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != cfdns.MaxRecordsPerPage || fake.Calls(http.MethodGet) != 1 {
+		t.Errorf("Expected %d records in one page, got %d records in %d pages",
+			cfdns.MaxRecordsPerPage, len(records), fake.Calls(http.MethodGet))
+	}
+}
@@ -1,7 +1,9 @@
 package cfdns
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,80 +13,134 @@ import (
 	"github.com/simplesurance/cfdns/log"
 )
 
-// ListRecords lists DNS records on a zone.
+// ListRecords lists DNS records on a zone. By default records are sorted
+// by type, in ascending order. If the request is invalid the error is
+// returned by the iterator, without sending any request.
 //
 // API Reference: https://developers.cloudflare.com/api/operations/dns-records-for-a-zone-list-dns-records
 func (c *Client) ListRecords(
 	req *ListRecordsRequest,
 ) *Iterator[ListRecordsResponseItem] {
-	page := 0
-	total := 0
-	read := 0
-
-	return &Iterator[ListRecordsResponseItem]{
-		fetchNext: func(
-			ctx context.Context,
-		) ([]*ListRecordsResponseItem, bool, error) {
-			page++
-
-			queryParams := url.Values{
-				"direction": {"asc"},
-				"per_page":  {strconv.Itoa(itemsPerPage)},
-				"page":      {strconv.Itoa(page)},
-				"order":     {"type"},
-			}
-
-			if req.Name != "" {
-				queryParams.Set("name", req.Name)
-			}
-
-			if req.Type != "" {
-				queryParams.Set("type", req.Type)
-			}
+	err := req.validate()
+	if err != nil {
+		return newFailedIterator[ListRecordsResponseItem](err)
+	}
 
-			resp, err := sendRequestRetry[*listRecordsAPIResponse](
-				ctx,
-				c,
-				c.logger.SubLogger(log.WithPrefix("ListRecords"), log.WithInt("page", page)),
-				&request{
-					method:      http.MethodGet,
-					path:        fmt.Sprintf("zones/%s/dns_records", url.PathEscape(req.ZoneID)),
-					queryParams: queryParams,
-					body:        nil,
-				})
-			if err != nil {
-				return nil, false, err
+	perPage := cmp.Or(req.PerPage, itemsPerPage)
+	order := cmp.Or(req.Order, RecordOrderType)
+	direction := cmp.Or(req.Direction, Ascending)
+
+	it := newIterator(perPage, c.listPrefetch, func(
+		ctx context.Context,
+		page int,
+	) (*fetchedPage[ListRecordsResponseItem], error) {
+		queryParams := url.Values{
+			"direction": {string(direction)},
+			"per_page":  {strconv.Itoa(perPage)},
+			"page":      {strconv.Itoa(page)},
+			"order":     {string(order)},
+		}
+
+		if req.Name != "" {
+			queryParams.Set("name", req.Name)
+		}
+
+		if req.Type != "" {
+			queryParams.Set("type", req.Type)
+		}
+
+		resp, err := sendRequestRetry[*listRecordsAPIResponse](
+			ctx,
+			c,
+			c.logger.SubLogger(log.WithPrefix("ListRecords"), log.WithInt("page", page)),
+			&request{
+				operation:   "ListRecords",
+				zoneID:      req.ZoneID,
+				recordType:  req.Type,
+				method:      http.MethodGet,
+				path:        fmt.Sprintf("zones/%s/dns_records", url.PathEscape(req.ZoneID)),
+				queryParams: queryParams,
+				body:        nil,
+
+				maxResponseLength: max(maxResponseLength, perPage*maxRecordLength),
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]*ListRecordsResponseItem, len(resp.body.Result))
+		for i, v := range resp.body.Result {
+			items[i] = &ListRecordsResponseItem{
+				ID:      v.ID,
+				Name:    v.Name,
+				Type:    v.Type,
+				Content: v.Content,
+				Proxied: v.Proxied,
+				Comment: v.Comment,
+				Tags:    v.Tags,
 			}
 
-			items := make([]*ListRecordsResponseItem, len(resp.body.Result))
-			for i, v := range resp.body.Result {
-				items[i] = &ListRecordsResponseItem{
-					ID:      v.ID,
-					Name:    v.Name,
-					Type:    v.Type,
-					Content: v.Content,
-					Proxied: v.Proxied,
-					Comment: v.Comment,
-				}
-
-				if v.TTL > 1 {
-					items[i].TTL = time.Second * time.Duration(v.TTL)
-				}
+			if v.TTL > 1 {
+				items[i].TTL = time.Second * time.Duration(v.TTL)
 			}
-
-			total = resp.body.ResultInfo.TotalCount
-			read += len(resp.body.Result)
-			isLast := read >= total
-
-			return items, isLast, nil
-		},
+		}
+
+		return &fetchedPage[ListRecordsResponseItem]{
+			items:      items,
+			perPage:    resp.body.ResultInfo.PerPage,
+			totalCount: resp.body.ResultInfo.TotalCount,
+		}, nil
+	})
+
+	it.detectChanges = req.DetectChanges
+	if req.Cursor != nil {
+		it.resume(*req.Cursor)
 	}
+
+	return it
 }
 
 type ListRecordsRequest struct {
 	ZoneID string
 	Name   string // Name is used to filter by name.
 	Type   string // Type is used to filter by type.
+
+	// PerPage is how many records are fetched on each request. The default
+	// is 500, and the maximum is MaxRecordsPerPage. The size allowed for
+	// responses grows with the page size.
+	PerPage int
+
+	// Order is the field used to sort records. The default is
+	// RecordOrderType.
+	Order RecordOrder
+
+	// Direction is the direction records are sorted. The default is
+	// Ascending.
+	Direction Direction
+
+	// Cursor makes the iterator resume from a position obtained with
+	// Iterator.Cursor, e.g., after an error.
+	Cursor *Cursor
+
+	// DetectChanges makes the iterator return ListChangedError when records
+	// are added or removed while the list is being read.
+	DetectChanges bool
+}
+
+func (req *ListRecordsRequest) validate() error {
+	if req == nil {
+		return errors.New("the request must not be nil")
+	}
+
+	return errors.Join(
+		validatePerPage(req.PerPage, MaxRecordsPerPage),
+		validateOrder(req.Order,
+			RecordOrderName,
+			RecordOrderType,
+			RecordOrderContent,
+			RecordOrderTTL,
+			RecordOrderProxied),
+		validateDirection(req.Direction))
 }
 
 type ListRecordsResponseItem struct {
@@ -94,6 +150,7 @@ type ListRecordsResponseItem struct {
 	Type    string
 	Proxied bool
 	Comment string
+	Tags    []string
 	TTL     time.Duration
 }
 
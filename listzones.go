@@ -1,7 +1,9 @@
 package cfdns
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -13,63 +15,120 @@ import (
 //
 // API Reference: https://developers.cloudflare.com/api/operations/zones-get
 func (c *Client) ListZones(
-	_ *ListZonesRequest,
+	req *ListZonesRequest,
 ) *Iterator[ListZonesResponseItem] {
-	page := 0
-	total := 0
-	read := 0
-
-	return &Iterator[ListZonesResponseItem]{
-		fetchNext: func(
-			ctx context.Context,
-		) ([]*ListZonesResponseItem, bool, error) {
-			page++
-
-			queryParams := url.Values{
-				"direction": {"asc"},
-				"per_page":  {strconv.Itoa(itemsPerPage)},
-			}
+	if req == nil {
+		req = &ListZonesRequest{}
+	}
 
-			if page != 0 {
-				queryParams["page"] = []string{strconv.Itoa(page)}
-			}
+	err := req.validate()
+	if err != nil {
+		return newFailedIterator[ListZonesResponseItem](err)
+	}
 
-			resp, err := sendRequestRetry[*listZoneAPIResponse](
-				ctx,
-				c,
-				c.logger.SubLogger(log.WithPrefix("ListZones"), log.WithInt("page", page)),
-				&request{
-					method:      http.MethodGet,
-					path:        "zones",
-					queryParams: queryParams,
-					body:        nil,
-				})
-			if err != nil {
-				return nil, false, err
-			}
+	perPage := cmp.Or(req.PerPage, zonesPerPage)
+	direction := cmp.Or(req.Direction, Ascending)
 
-			items := make([]*ListZonesResponseItem, len(resp.body.Result))
-			for i, v := range resp.body.Result {
-				items[i] = &ListZonesResponseItem{
-					ID:   v.ID,
-					Name: v.Name,
-				}
+	it := newIterator(perPage, c.listPrefetch, func(
+		ctx context.Context,
+		page int,
+	) (*fetchedPage[ListZonesResponseItem], error) {
+		queryParams := url.Values{
+			"direction": {string(direction)},
+			"per_page":  {strconv.Itoa(perPage)},
+			"page":      {strconv.Itoa(page)},
+		}
+
+		if req.Name != "" {
+			queryParams.Set("name", req.Name)
+		}
+
+		if req.Order != "" {
+			queryParams.Set("order", string(req.Order))
+		}
+
+		resp, err := sendRequestRetry[*listZoneAPIResponse](
+			ctx,
+			c,
+			c.logger.SubLogger(log.WithPrefix("ListZones"), log.WithInt("page", page)),
+			&request{
+				operation:   "ListZones",
+				method:      http.MethodGet,
+				path:        "zones",
+				queryParams: queryParams,
+				body:        nil,
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]*ListZonesResponseItem, len(resp.body.Result))
+		for i, v := range resp.body.Result {
+			items[i] = &ListZonesResponseItem{
+				ID:          v.ID,
+				Name:        v.Name,
+				NameServers: v.NameServers,
 			}
+		}
 
-			total = resp.body.ResultInfo.TotalCount
-			read += len(resp.body.Result)
-			isLast := read >= total
+		return &fetchedPage[ListZonesResponseItem]{
+			items:      items,
+			perPage:    resp.body.ResultInfo.PerPage,
+			totalCount: resp.body.ResultInfo.TotalCount,
+		}, nil
+	})
 
-			return items, isLast, nil
-		},
+	it.detectChanges = req.DetectChanges
+	if req.Cursor != nil {
+		it.resume(*req.Cursor)
 	}
+
+	return it
 }
 
-type ListZonesRequest struct{}
+type ListZonesRequest struct {
+	// Name is used to filter by the domain name of the zone.
+	Name string
+
+	// PerPage is how many zones are fetched on each request. The default
+	// is 50.
+	PerPage int
+
+	// Order is the field used to sort zones. By default the order is
+	// defined by CloudFlare.
+	Order ZoneOrder
+
+	// Direction is the direction zones are sorted. The default is
+	// Ascending.
+	Direction Direction
+
+	// Cursor makes the iterator resume from a position obtained with
+	// Iterator.Cursor, e.g., after an error.
+	Cursor *Cursor
+
+	// DetectChanges makes the iterator return ListChangedError when zones
+	// are added or removed while the list is being read.
+	DetectChanges bool
+}
+
+func (req *ListZonesRequest) validate() error {
+	return errors.Join(
+		validatePerPage(req.PerPage, MaxZonesPerPage),
+		validateOrder(req.Order,
+			ZoneOrderName,
+			ZoneOrderStatus,
+			ZoneOrderAccountID,
+			ZoneOrderAccountName),
+		validateDirection(req.Direction))
+}
 
 type ListZonesResponseItem struct {
 	ID   string
 	Name string
+
+	// NameServers are the authoritative nameservers CloudFlare assigned to
+	// the zone.
+	NameServers []string
 }
 
 type listZoneAPIResponse struct {
@@ -79,6 +138,7 @@ type listZoneAPIResponse struct {
 }
 
 type listZoneAPIResponseItem struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	NameServers []string `json:"name_servers"`
 }
@@ -0,0 +1,137 @@
+// Package asynctarget is a log driver that wraps another driver, sending
+// log entries to it asynchronously, from a separate goroutine.
+//
+// Entries are kept on a bounded queue. When the queue is full entries are
+// either dropped or the caller is blocked, according to the configured
+// policy. Close must be called to release the goroutine, after which
+// entries are dropped.
+package asynctarget
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/simplesurance/cfdns/log"
+)
+
+// Policy defines what happens when an entry is logged and the queue is
+// full.
+type Policy int
+
+const (
+	// Drop drops the entry, incrementing the counter returned by Dropped.
+	Drop Policy = iota
+
+	// Block blocks the caller until there is space on the queue.
+	Block
+)
+
+// Driver is the log driver created by New.
+type Driver struct {
+	driver log.Driver
+	policy Policy
+	queue  chan item
+
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+type item struct {
+	entry   *log.Entry
+	flushed chan struct{}
+}
+
+// New creates a driver that sends entries to the provided driver from a
+// separate goroutine. Up to queueSize entries are kept while waiting to be
+// sent.
+func New(d log.Driver, queueSize int, policy Policy) *Driver {
+	ret := &Driver{
+		driver: d,
+		policy: policy,
+		queue:  make(chan item, queueSize),
+		done:   make(chan struct{}),
+	}
+
+	go ret.run()
+
+	return ret
+}
+
+func (d *Driver) run() {
+	defer close(d.done)
+
+	for it := range d.queue {
+		if it.flushed != nil {
+			close(it.flushed)
+			continue
+		}
+
+		d.driver.Send(it.entry)
+	}
+}
+
+func (d *Driver) Send(entry *log.Entry) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		d.dropped.Add(1)
+		return
+	}
+
+	if d.policy == Block {
+		d.queue <- item{entry: entry}
+		return
+	}
+
+	select {
+	case d.queue <- item{entry: entry}:
+	default:
+		d.dropped.Add(1)
+	}
+}
+
+func (d *Driver) PreLog() func() {
+	return nil
+}
+
+// Dropped returns how many entries were dropped, because the queue was
+// full or the driver was closed.
+func (d *Driver) Dropped() uint64 {
+	return d.dropped.Load()
+}
+
+// Flush blocks until all entries logged before it was called were sent to
+// the wrapped driver.
+func (d *Driver) Flush() {
+	d.mu.RLock()
+
+	if d.closed {
+		d.mu.RUnlock()
+		return
+	}
+
+	flushed := make(chan struct{})
+	d.queue <- item{flushed: flushed}
+	d.mu.RUnlock()
+
+	<-flushed
+}
+
+// Close sends all queued entries to the wrapped driver and stops the
+// goroutine. Entries logged after Close are dropped.
+func (d *Driver) Close() {
+	d.mu.Lock()
+
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	<-d.done
+}
+
+var _ log.Driver = &Driver{}
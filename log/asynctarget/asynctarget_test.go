@@ -0,0 +1,76 @@
+package asynctarget_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/asynctarget"
+)
+
+// blockingDriver blocks sending entries until unblocked.
+type blockingDriver struct {
+	unblock chan struct{}
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (b *blockingDriver) Send(e *log.Entry) {
+	<-b.unblock
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, e.Message)
+}
+
+func (b *blockingDriver) PreLog() func() { return nil }
+
+func TestDropWhenFull(t *testing.T) {
+	inner := &blockingDriver{unblock: make(chan struct{})}
+	driver := asynctarget.New(inner, 2, asynctarget.Drop)
+	logger := log.New(driver)
+
+	// one entry is being sent and two are on the queue; the rest is dropped
+	for range 10 {
+		logger.I("message")
+	}
+
+	close(inner.unblock)
+	driver.Flush()
+
+	inner.mu.Lock()
+	sent := len(inner.messages)
+	inner.mu.Unlock()
+
+	if uint64(sent)+driver.Dropped() != 10 || sent < 2 || sent > 3 {
+		t.Errorf("Unexpected result: sent=%d dropped=%d", sent, driver.Dropped())
+	}
+
+	driver.Close()
+	logger.I("after close")
+
+	if uint64(sent)+driver.Dropped() != 11 {
+		t.Errorf("Entries logged after Close must be dropped")
+	}
+}
+
+func TestBlockKeepsAllEntries(t *testing.T) {
+	inner := &blockingDriver{unblock: make(chan struct{})}
+	close(inner.unblock)
+
+	driver := asynctarget.New(inner, 1, asynctarget.Block)
+	logger := log.New(driver)
+
+	for range 100 {
+		logger.I("message")
+	}
+
+	driver.Close()
+
+	if len(inner.messages) != 100 || driver.Dropped() != 0 {
+		t.Errorf("Expected all entries to be sent, got sent=%d dropped=%d",
+			len(inner.messages), driver.Dropped())
+	}
+}
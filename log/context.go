@@ -0,0 +1,33 @@
+package log
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+type tagsCtxKey struct{}
+
+// ContextWithTags returns a context carrying the provided tag options, like
+// WithString. Libraries that receive the context can include the tags on
+// their log entries, allowing correlating them with the caller.
+//
+// Tags added to a context that already carries tags are appended to them.
+func ContextWithTags(ctx context.Context, opts ...Option) context.Context {
+	tags := slices.Concat(TagsFromContext(ctx), opts)
+
+	return context.WithValue(ctx, tagsCtxKey{}, tags)
+}
+
+// TagsFromContext returns the tag options added to the context with
+// ContextWithTags. They can be provided to SubLogger or to a log call.
+func TagsFromContext(ctx context.Context) []Option {
+	tags, _ := ctx.Value(tagsCtxKey{}).([]Option)
+
+	return slices.Clip(tags)
+}
+
+// TagValues returns the tags that the provided options add to a log entry.
+func TagValues(opts ...Option) map[string]any {
+	return maps.Clone(applyOptions(opts...).Tags)
+}
@@ -0,0 +1,149 @@
+// Package deduptarget is a log driver that wraps another driver, collapsing
+// repeated messages into a single one with a count.
+//
+// The first message is sent immediately to the wrapped driver. Identical
+// messages sent during the following window are dropped; when the window
+// ends, if any message was dropped, a message with the same text and a
+// "repeated" tag with the number of dropped messages is sent.
+//
+// Messages are identical if they have the same severity and text. Tags are
+// not compared, the tags of the message sent when the window ends are the
+// ones from the last dropped message. Error messages are never dropped.
+//
+// Close must be called on shutdown, otherwise the count of messages dropped
+// on windows that did not end yet is lost.
+package deduptarget
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+)
+
+// Driver is the log driver created by New.
+type Driver struct {
+	driver log.Driver
+	window time.Duration
+
+	mu      sync.Mutex
+	closed  bool
+	pending map[dedupKey]*pending
+}
+
+type dedupKey struct {
+	severity log.Severity
+	message  string
+}
+
+type pending struct {
+	last  *log.Entry
+	count int
+	timer *time.Timer
+}
+
+// New creates a driver that sends messages to the provided driver,
+// collapsing identical messages sent during the window.
+func New(d log.Driver, window time.Duration) *Driver {
+	return &Driver{
+		driver:  d,
+		window:  window,
+		pending: map[dedupKey]*pending{},
+	}
+}
+
+func (d *Driver) Send(entry *log.Entry) {
+	if entry.Severity >= log.Error {
+		d.driver.Send(entry)
+		return
+	}
+
+	key := dedupKey{severity: entry.Severity, message: entry.Message}
+
+	d.mu.Lock()
+
+	if d.closed {
+		d.mu.Unlock()
+		d.driver.Send(entry)
+
+		return
+	}
+
+	if p, ok := d.pending[key]; ok {
+		p.count++
+		p.last = entry
+		d.mu.Unlock()
+
+		return
+	}
+
+	d.pending[key] = &pending{
+		timer: time.AfterFunc(d.window, func() { d.flushKey(key) }),
+	}
+	d.mu.Unlock()
+
+	d.driver.Send(entry)
+}
+
+func (d *Driver) PreLog() func() {
+	return d.driver.PreLog()
+}
+
+// Flush sends the summary of all messages that were dropped, without
+// waiting for their windows to end.
+func (d *Driver) Flush() {
+	d.mu.Lock()
+	keys := make([]dedupKey, 0, len(d.pending))
+
+	for k, p := range d.pending {
+		p.timer.Stop()
+		keys = append(keys, k)
+	}
+	d.mu.Unlock()
+
+	for _, k := range keys {
+		d.flushKey(k)
+	}
+}
+
+// Close sends the summary of all messages that were dropped and stops the
+// timers of the windows. Messages logged after Close are sent to the
+// wrapped driver without deduplication.
+func (d *Driver) Close() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	d.Flush()
+}
+
+func (d *Driver) flushKey(key dedupKey) {
+	d.mu.Lock()
+
+	p, ok := d.pending[key]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+
+	delete(d.pending, key)
+	d.mu.Unlock()
+
+	if p.count == 0 {
+		return
+	}
+
+	summary := *p.last
+	summary.Tags = maps.Clone(p.last.Tags)
+
+	if summary.Tags == nil {
+		summary.Tags = map[string]any{}
+	}
+
+	summary.Tags["repeated"] = p.count
+
+	d.driver.Send(&summary)
+}
+
+var _ log.Driver = &Driver{}
@@ -0,0 +1,88 @@
+package deduptarget_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/deduptarget"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	entries []*log.Entry
+}
+
+func (r *recorder) Send(e *log.Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, e)
+}
+
+func (r *recorder) PreLog() func() { return nil }
+
+func TestDedup(t *testing.T) {
+	rec := &recorder{}
+	driver := deduptarget.New(rec, time.Hour)
+	logger := log.New(driver)
+
+	for i := range 5 {
+		logger.W("f() returned an error", log.WithInt("attempt", i+1))
+	}
+
+	logger.W("other warning")
+	logger.E("f() returned an error")
+	logger.E("f() returned an error")
+
+	if len(rec.entries) != 4 {
+		t.Fatalf("Expected 4 messages before flushing, got %d", len(rec.entries))
+	}
+
+	driver.Flush()
+
+	if len(rec.entries) != 5 {
+		t.Fatalf("Expected 5 messages after flushing, got %d", len(rec.entries))
+	}
+
+	summary := rec.entries[4]
+	if summary.Message != "f() returned an error" || summary.Severity != log.Warn {
+		t.Errorf("Unexpected summary message %+v", summary)
+	}
+
+	if summary.Tags["repeated"] != 4 || summary.Tags["attempt"] != 5 {
+		t.Errorf("Unexpected summary tags %v", summary.Tags)
+	}
+
+	// after flushing, messages are not suppressed anymore
+	logger.W("f() returned an error")
+
+	if len(rec.entries) != 6 {
+		t.Fatalf("Expected message to be sent after flushing")
+	}
+}
+
+func TestDedupClose(t *testing.T) {
+	rec := &recorder{}
+	driver := deduptarget.New(rec, time.Hour)
+	logger := log.New(driver)
+
+	for range 3 {
+		logger.I("Connection reset")
+	}
+
+	driver.Close()
+
+	if len(rec.entries) != 2 || rec.entries[1].Tags["repeated"] != 2 {
+		t.Fatalf("Expected the summary to be sent on Close, got %d messages", len(rec.entries))
+	}
+
+	// after Close messages are not suppressed
+	logger.I("Connection reset")
+	logger.I("Connection reset")
+
+	if len(rec.entries) != 4 {
+		t.Errorf("Expected messages to be sent after Close, got %d messages", len(rec.entries))
+	}
+}
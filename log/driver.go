@@ -1,6 +1,8 @@
 package log
 
 import (
+	"fmt"
+	"path/filepath"
 	"time"
 )
 
@@ -29,6 +31,10 @@ type Entry struct {
 	Message   string
 	Caller    caller
 	Severity  Severity
+
+	// Stack is the stack trace of the goroutine that logged the entry. It
+	// is only set for error entries, if enabled with WithErrorStackTrace.
+	Stack string
 }
 
 type caller struct {
@@ -36,6 +42,16 @@ type caller struct {
 	Line int
 }
 
+// String returns the caller in the short form "dir/file.go:line", or an
+// empty string if the caller is unknown.
+func (c caller) String() string {
+	if c.File == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s:%d", filepath.Base(filepath.Dir(c.File)), filepath.Base(c.File), c.Line)
+}
+
 type Severity int
 
 func (s Severity) String() string {
@@ -0,0 +1,100 @@
+// Package jsontarget is a log driver that encodes log messages as JSON
+// lines and writes them to a provided writer.
+//
+// Each log entry is written as a single JSON object on its own line, with
+// the fields "time", "severity", "message", "caller", "tags" and, if a
+// stack trace was captured, "stack". Errors
+// are encoded as their message and durations as strings, like "1.5s".
+// Groups are encoded as nested objects.
+package jsontarget
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+)
+
+func New(w io.Writer) log.Driver {
+	return &logger{w: w}
+}
+
+type logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+type jsonEntry struct {
+	Time     string         `json:"time"`
+	Severity string         `json:"severity"`
+	Message  string         `json:"message"`
+	Caller   *log.Caller    `json:"caller,omitempty"`
+	Tags     map[string]any `json:"tags,omitempty"`
+	Stack    string         `json:"stack,omitempty"`
+}
+
+func (l *logger) Send(entry *log.Entry) {
+	je := jsonEntry{
+		Time:     entry.Timestamp.Format(time.RFC3339Nano),
+		Severity: entry.Severity.String(),
+		Message:  entry.Message,
+		Stack:    entry.Stack,
+	}
+
+	if entry.Caller.File != "" {
+		je.Caller = &log.Caller{
+			File: entry.Caller.File,
+			Line: entry.Caller.Line,
+		}
+	}
+
+	if len(entry.Tags) > 0 {
+		je.Tags = make(map[string]any, len(entry.Tags))
+		for k, v := range entry.Tags {
+			je.Tags[k] = jsonValue(v)
+		}
+	}
+
+	data, err := json.Marshal(je)
+	if err != nil {
+		// a tag can't be encoded; still log the message
+		je.Tags = map[string]any{"log_encoding_error": err.Error()}
+		data, _ = json.Marshal(je)
+	}
+
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = l.w.Write(data)
+}
+
+func (l *logger) PreLog() func() {
+	return nil
+}
+
+func jsonValue(v any) any {
+	switch vt := v.(type) {
+	case log.Group:
+		group := make(map[string]any, len(vt))
+		for k, v := range vt {
+			group[k] = jsonValue(v)
+		}
+
+		return group
+	case time.Time:
+		return vt.Format(time.RFC3339Nano)
+	case error:
+		return vt.Error()
+	case time.Duration:
+		return vt.String()
+	case fmt.Stringer:
+		return vt.String()
+	}
+
+	return v
+}
@@ -0,0 +1,73 @@
+package jsontarget_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/jsontarget"
+)
+
+func TestJSONTarget(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := log.New(jsontarget.New(buf), log.WithPrefix("Test"))
+
+	logger.W("Something happened",
+		log.WithString("key", "value"),
+		log.WithInt("count", 3),
+		log.WithDuration("delay", 1500*time.Millisecond),
+		log.WithError(io.EOF),
+		log.WithGroup("http", log.WithInt("status", 200)))
+	logger.I("Second message")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+
+	var entry struct {
+		Time     time.Time      `json:"time"`
+		Severity string         `json:"severity"`
+		Message  string         `json:"message"`
+		Caller   log.Caller     `json:"caller"`
+		Tags     map[string]any `json:"tags"`
+	}
+
+	err := json.Unmarshal([]byte(lines[0]), &entry)
+	if err != nil {
+		t.Fatalf("Invalid JSON %q: %v", lines[0], err)
+	}
+
+	if entry.Severity != "warn" || entry.Message != "Test: Something happened" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	if time.Since(entry.Time) > time.Minute {
+		t.Errorf("Unexpected timestamp %v", entry.Time)
+	}
+
+	if !strings.HasSuffix(entry.Caller.File, "jsontarget_test.go") {
+		t.Errorf("Unexpected caller %+v", entry.Caller)
+	}
+
+	want := map[string]any{
+		"key":   "value",
+		"count": float64(3),
+		"delay": "1.5s",
+		"error": "EOF",
+	}
+	for k, v := range want {
+		if entry.Tags[k] != v {
+			t.Errorf("Tag %s: want %v, have %v", k, v, entry.Tags[k])
+		}
+	}
+
+	group, ok := entry.Tags["http"].(map[string]any)
+	if !ok || group["status"] != float64(200) {
+		t.Errorf("Expected group to be encoded as an object, got %v", entry.Tags["http"])
+	}
+}
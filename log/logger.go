@@ -1,8 +1,10 @@
 package log
 
 import (
+	"fmt"
 	"maps"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -20,6 +22,8 @@ func New(d Driver, opt ...Option) *Logger {
 type Logger struct {
 	driver         Driver
 	debugEnabledFn func() bool
+	minSeverity    Severity
+	sampler        *sampler
 	options        []Option
 }
 
@@ -53,7 +57,7 @@ func (l *Logger) SubLogger(opts ...Option) *Logger {
 // not enabled, its cost can be avoided in production, while still keeping
 // the ability of debugging issues when they happen.
 func (l *Logger) D(lf func(lg DebugFn)) {
-	if !l.debugEnabledFn() {
+	if Debug < l.minSeverity || !l.debugEnabledFn() {
 		return
 	}
 
@@ -95,6 +99,10 @@ func (l *Logger) d(msg string, opt ...Option) {
 }
 
 func (l *Logger) log(msg string, sev Severity, opt ...Option) {
+	if sev < l.minSeverity {
+		return
+	}
+
 	if helper := l.driver.PreLog(); helper != nil {
 		helper()
 	}
@@ -111,20 +119,40 @@ func (l *Logger) log(msg string, sev Severity, opt ...Option) {
 		msgWithPrefix = loggerOpts.logPrefix + ": " + msg
 	}
 
+	now := time.Now()
+
+	if l.sampler != nil && sev < Error {
+		ok, dropped := l.sampler.sample(now, msgWithPrefix, tags)
+		if !ok {
+			return
+		}
+
+		if dropped > 0 {
+			tags["sampled_out"] = dropped
+		}
+	}
+
+	resolveLazy(tags)
+
 	entry := &Entry{
-		Timestamp: time.Now(),
+		Timestamp: now,
 		Message:   msgWithPrefix,
 		Severity:  sev,
 		Tags:      tags,
 	}
 
 	// include caller
-	_, file, line, ok := runtime.Caller(loggerOpts.callersToSkip)
-	if ok {
+	if loggerOpts.caller != nil {
+		entry.Caller = caller(*loggerOpts.caller)
+	} else if _, file, line, ok := runtime.Caller(loggerOpts.callersToSkip); ok {
 		entry.Caller.File = file
 		entry.Caller.Line = line
 	}
 
+	if sev == Error && (loggerOpts.errorStack || messageOpts.errorStack) {
+		entry.Stack = stackTrace(loggerOpts.callersToSkip + 1)
+	}
+
 	l.driver.Send(entry)
 }
 
@@ -134,9 +162,32 @@ func (l *Logger) loadOptions() {
 	settings := applyOptions(l.options...)
 
 	l.debugEnabledFn = settings.debugEnabledFn
+	l.minSeverity = settings.minSeverity
+	l.sampler = settings.sampler
 }
 
 type Caller struct {
 	File string `json:"file"`
 	Line int    `json:"line"`
 }
+
+// stackTrace returns the stack trace of the current goroutine, skipping the
+// provided number of frames, as runtime.Caller.
+func stackTrace(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	ret := &strings.Builder{}
+
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(ret, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+
+		if !more {
+			break
+		}
+	}
+
+	return ret.String()
+}
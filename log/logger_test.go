@@ -2,6 +2,8 @@ package log_test
 
 import (
 	"io"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -59,3 +61,152 @@ func TestDebugEnabled(t *testing.T) {
 		log("message 2")
 	})
 }
+
+func TestMinSeverity(t *testing.T) {
+	rec := &recorder{}
+	logger := log.New(rec,
+		log.WithMinSeverity(log.Warn),
+		log.WithDebugEnabledFn(func() bool { return true }))
+
+	logger.D(func(lg log.DebugFn) {
+		t.Error("Debug message must not be computed when below the minimum severity")
+	})
+	logger.I("Info")
+	logger.W("Warn")
+	logger.SubLogger().E("Error")
+
+	assertMessages(t, rec, "Warn", "Error")
+}
+
+func TestSampling(t *testing.T) {
+	rec := &recorder{}
+	logger := log.New(rec, log.WithSampling(time.Hour, 2, "op"))
+
+	for range 5 {
+		logger.W("Retrying", log.WithString("op", "a"))
+		logger.SubLogger(log.WithString("op", "b")).W("Retrying")
+	}
+
+	logger.E("Retrying", log.WithString("op", "a"))
+
+	assertMessages(t, rec, "Retrying", "Retrying", "Retrying", "Retrying", "Retrying")
+}
+
+type recorder struct {
+	entries []*log.Entry
+}
+
+func (r *recorder) Send(e *log.Entry) { r.entries = append(r.entries, e) }
+func (r *recorder) PreLog() func()    { return nil }
+
+func assertMessages(t *testing.T, rec *recorder, want ...string) {
+	t.Helper()
+
+	have := make([]string, len(rec.entries))
+	for i, e := range rec.entries {
+		have[i] = e.Message
+	}
+
+	if strings.Join(have, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected messages:\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestTypedTags(t *testing.T) {
+	rec := &recorder{}
+	logger := log.New(rec, log.WithMinSeverity(log.Info))
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lazyCalls := 0
+	lazy := log.WithLazy("lazy", func() any {
+		lazyCalls++
+		return "computed"
+	})
+
+	logger.W("Typed",
+		log.WithBool("bool", true),
+		log.WithInt64("int64", 1<<40),
+		log.WithFloat64("float64", 0.5),
+		log.WithTime("time", ts),
+		log.WithNamedError("read_error", io.EOF),
+		log.WithError(io.ErrUnexpectedEOF),
+		lazy,
+		log.WithGroup("http",
+			log.WithString("method", "GET"),
+			log.WithInt("status", 200)))
+
+	logger.SubLogger(lazy).D(func(log.DebugFn) {})
+
+	if lazyCalls != 1 {
+		t.Errorf("Lazy value must be computed only for logged entries, was computed %d times", lazyCalls)
+	}
+
+	tags := rec.entries[0].Tags
+
+	for k, want := range map[string]any{
+		"bool":       true,
+		"int64":      int64(1 << 40),
+		"float64":    0.5,
+		"time":       ts,
+		"read_error": io.EOF,
+		"error":      io.ErrUnexpectedEOF,
+		"lazy":       "computed",
+	} {
+		if tags[k] != want {
+			t.Errorf("Tag %s: want %v (%T), have %v (%T)", k, want, want, tags[k], tags[k])
+		}
+	}
+
+	group, ok := tags["http"].(log.Group)
+	if !ok || group["method"] != "GET" || group["status"] != 200 {
+		t.Errorf("Unexpected group %#v", tags["http"])
+	}
+
+	flat := log.Flatten(tags)
+	if flat["http.status"] != 200 {
+		t.Errorf("Unexpected flattened tags %v", flat)
+	}
+}
+
+func TestCallerSkip(t *testing.T) {
+	rec := &recorder{}
+	logger := log.New(rec, log.WithErrorStackTrace())
+
+	logHelper := func(msg string) {
+		logger.SubLogger(log.WithCallerSkip(1)).E(msg)
+	}
+
+	logHelper("from helper") // the caller must be this line
+	_, _, wantLine, _ := runtime.Caller(0)
+
+	entry := rec.entries[0]
+	if !strings.HasSuffix(entry.Caller.File, "logger_test.go") || entry.Caller.Line != wantLine-1 {
+		t.Errorf("Expected caller to be logger_test.go:%d, got %s:%d",
+			wantLine-1, entry.Caller.File, entry.Caller.Line)
+	}
+
+	if !strings.HasPrefix(entry.Stack, "github.com/simplesurance/cfdns/log_test.TestCallerSkip") {
+		t.Errorf("Expected stack trace to start on the caller, got:\n%s", entry.Stack)
+	}
+
+	logger.W("warning")
+
+	if rec.entries[1].Stack != "" {
+		t.Error("Stack trace must only be captured for errors")
+	}
+}
+
+func TestWithCaller(t *testing.T) {
+	rec := &recorder{}
+	logger := log.New(rec,
+		log.WithCaller(log.Caller{File: "/src/app/main.go", Line: 42}),
+		log.WithDebugEnabledFn(func() bool { return true }))
+
+	logger.W("warning")
+	logger.D(func(lg log.DebugFn) { lg("debug") })
+
+	for _, e := range rec.entries {
+		if e.Caller.File != "/src/app/main.go" || e.Caller.Line != 42 {
+			t.Errorf("Unexpected caller of %q: %s:%d", e.Message, e.Caller.File, e.Caller.Line)
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package log
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type options struct {
 	Tags           map[string]any
 	debugEnabledFn func() bool
 	logPrefix      string
 	callersToSkip  int
+	caller         *Caller
 	Severity       Severity
+	minSeverity    Severity
+	sampler        *sampler
+	errorStack     bool
 }
 
 func applyOptions(opts ...Option) options {
@@ -43,9 +50,89 @@ func WithDuration(key string, val time.Duration) Option {
 	}
 }
 
+// WithError adds the error with the key "error". If the error, or an error
+// it wraps, implements ErrorTagger, its tags are also added.
 func WithError(err error) Option {
 	return func(o *options) {
 		o.Tags["error"] = err
+
+		var tagger ErrorTagger
+		if errors.As(err, &tagger) {
+			for _, opt := range tagger.LogTags() {
+				opt(o)
+			}
+		}
+	}
+}
+
+// ErrorTagger is implemented by errors that carry information useful to
+// correlate log entries, like the ID of a failed request. Its tags are
+// added to entries logged with WithError.
+type ErrorTagger interface {
+	LogTags() []Option
+}
+
+// WithNamedError adds an error with the provided key. It allows adding
+// more than one error to the same entry; WithError always uses the key
+// "error".
+func WithNamedError(key string, err error) Option {
+	return func(o *options) {
+		o.Tags[key] = err
+	}
+}
+
+func WithBool(key string, val bool) Option {
+	return func(o *options) {
+		o.Tags[key] = val
+	}
+}
+
+func WithInt64(key string, val int64) Option {
+	return func(o *options) {
+		o.Tags[key] = val
+	}
+}
+
+func WithFloat64(key string, val float64) Option {
+	return func(o *options) {
+		o.Tags[key] = val
+	}
+}
+
+func WithTime(key string, val time.Time) Option {
+	return func(o *options) {
+		o.Tags[key] = val
+	}
+}
+
+// WithAny adds a value of any type. Drivers may not know how to encode
+// arbitrary types, and fall back to formatting them with fmt.
+func WithAny(key string, val any) Option {
+	return func(o *options) {
+		o.Tags[key] = val
+	}
+}
+
+// WithLazy adds a value that is only computed if the entry is sent to the
+// driver. Entries dropped because of their severity or by sampling don't
+// call fn. The function is called once for each entry.
+func WithLazy(key string, fn func() any) Option {
+	return func(o *options) {
+		o.Tags[key] = lazyValue(fn)
+	}
+}
+
+// WithGroup adds the tags from the provided options under a namespace.
+// Drivers receive them as a Group.
+//
+// Example:
+//
+//	logger.I("Request sent", log.WithGroup("http",
+//		log.WithString("method", "GET"),
+//		log.WithInt("status", 200)))
+func WithGroup(name string, opts ...Option) Option {
+	return func(o *options) {
+		o.Tags[name] = Group(applyOptions(opts...).Tags)
 	}
 }
 
@@ -68,3 +155,59 @@ func WithDebugEnabledFn(enabledFn func() bool) Option {
 		o.debugEnabledFn = enabledFn
 	}
 }
+
+// WithMinSeverity configures the logger to drop messages with a severity
+// lower than the provided one. Debug messages are only logged if they are
+// also enabled with WithDebugEnabledFn.
+func WithMinSeverity(sev Severity) Option {
+	return func(o *options) {
+		o.minSeverity = sev
+	}
+}
+
+// WithSampling limits how many messages with the same key are logged.
+// At most burst messages with the same key are logged on each interval;
+// the remaining are dropped. The first message logged after messages were
+// dropped has a "sampled_out" tag with the number of dropped messages.
+//
+// The key of a message is its text and the values of the tags with the
+// provided names. Error messages are never dropped. Up to 1024 keys are
+// tracked; when more keys have messages logged during the same interval,
+// messages with new keys are not sampled.
+//
+// Sub-loggers share the same sampling state with their parent.
+func WithSampling(interval time.Duration, burst int, keyTags ...string) Option {
+	s := newSampler(interval, burst, keyTags)
+
+	return func(o *options) {
+		o.sampler = s
+	}
+}
+
+// WithCallerSkip makes the logger skip additional stack frames when
+// determining the caller of log entries. It is useful when the logger is
+// used by a helper function, allowing entries to point to the caller of the
+// helper instead. The value is added to the skip of the parent logger.
+func WithCallerSkip(skip int) Option {
+	return func(o *options) {
+		o.callersToSkip += skip
+	}
+}
+
+// WithCaller makes the logger use the provided caller for all entries,
+// instead of determining it from the stack. It is useful when entries are
+// logged on behalf of code that is not on a fixed number of frames above,
+// like the code calling a library.
+func WithCaller(caller Caller) Option {
+	return func(o *options) {
+		o.caller = &caller
+	}
+}
+
+// WithErrorStackTrace makes the logger capture the stack trace of the
+// goroutine that logs an error entry, storing it on Entry.Stack.
+func WithErrorStackTrace() Option {
+	return func(o *options) {
+		o.errorStack = true
+	}
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSamplerKeys is how many keys the sampler keeps. When there are more
+// keys with active windows, messages with new keys are not sampled.
+const maxSamplerKeys = 1024
+
+type sampler struct {
+	interval time.Duration
+	burst    int
+	keyTags  []string
+
+	mu      sync.Mutex
+	windows map[string]*sampleWindow
+}
+
+type sampleWindow struct {
+	start   time.Time
+	count   int
+	dropped int
+}
+
+func newSampler(interval time.Duration, burst int, keyTags []string) *sampler {
+	return &sampler{
+		interval: interval,
+		burst:    burst,
+		keyTags:  keyTags,
+		windows:  map[string]*sampleWindow{},
+	}
+}
+
+// sample returns if a message must be logged, and how many messages with
+// the same key were dropped since the last one logged.
+func (s *sampler) sample(now time.Time, msg string, tags map[string]any) (_ bool, dropped int) {
+	key := s.key(msg, tags)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows[key]
+	switch {
+	case !ok:
+		s.removeExpired(now)
+
+		if len(s.windows) >= maxSamplerKeys {
+			// keeps the memory bounded when tags of the key have too
+			// many distinct values
+			return true, 0
+		}
+
+		w = &sampleWindow{start: now}
+		s.windows[key] = w
+	case now.Sub(w.start) >= s.interval:
+		w.start = now
+		w.count = 0
+	}
+
+	w.count++
+	if w.count > s.burst {
+		w.dropped++
+		return false, 0
+	}
+
+	dropped = w.dropped
+	w.dropped = 0
+
+	return true, dropped
+}
+
+func (s *sampler) key(msg string, tags map[string]any) string {
+	key := &strings.Builder{}
+	key.WriteString(msg)
+
+	for _, t := range s.keyTags {
+		fmt.Fprintf(key, "\x00%v", tags[t])
+	}
+
+	return key.String()
+}
+
+// removeExpired removes windows that expired, if there are too many. The
+// count of messages dropped on a removed window is discarded. Must be
+// called with the lock held.
+func (s *sampler) removeExpired(now time.Time) {
+	if len(s.windows) < maxSamplerKeys {
+		return
+	}
+
+	for k, w := range s.windows {
+		if now.Sub(w.start) >= s.interval {
+			delete(s.windows, k)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSamplerRemovesExpiredWindows(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newSampler(time.Minute, 1, []string{"id"})
+
+	// every key has messages dropped, and none is logged again
+	for i := range maxSamplerKeys {
+		tags := map[string]any{"id": strconv.Itoa(i)}
+
+		s.sample(now, "msg", tags)
+
+		if ok, _ := s.sample(now, "msg", tags); ok {
+			t.Fatal("Expected the second message to be dropped")
+		}
+	}
+
+	// keys beyond the limit are not sampled while windows are active
+	for range 2 {
+		if ok, _ := s.sample(now, "msg", map[string]any{"id": "new"}); !ok {
+			t.Fatal("Expected messages with a new key to be logged")
+		}
+	}
+
+	if len(s.windows) != maxSamplerKeys {
+		t.Fatalf("Expected %d windows, have %d", maxSamplerKeys, len(s.windows))
+	}
+
+	// expired windows are removed, even if they have dropped messages
+	now = now.Add(time.Minute)
+
+	if ok, _ := s.sample(now, "msg", map[string]any{"id": "new"}); !ok {
+		t.Fatal("Expected message to be logged")
+	}
+
+	if len(s.windows) != 1 {
+		t.Errorf("Expected only the new window, have %d", len(s.windows))
+	}
+}
@@ -0,0 +1,94 @@
+// Package slogtarget is a log driver that forwards log messages to a
+// handler from the standard log/slog package.
+//
+// It also allows using a *slog.Logger where a *log.Logger is expected,
+// with NewLogger.
+package slogtarget
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/simplesurance/cfdns/log"
+)
+
+// New creates a driver that sends log entries to the provided handler.
+func New(h slog.Handler) log.Driver {
+	return &logger{handler: h}
+}
+
+// NewLogger creates a logger that sends log entries to the provided slog
+// logger. Debug messages are enabled when the slog logger has debug level
+// enabled. Additional options are applied to the returned logger.
+func NewLogger(l *slog.Logger, opts ...log.Option) *log.Logger {
+	debugEnabled := func() bool {
+		return l.Enabled(context.Background(), slog.LevelDebug)
+	}
+
+	opts = append([]log.Option{log.WithDebugEnabledFn(debugEnabled)}, opts...)
+
+	return log.New(New(l.Handler()), opts...)
+}
+
+type logger struct {
+	handler slog.Handler
+}
+
+func (l *logger) Send(entry *log.Entry) {
+	ctx := context.Background()
+	level := toSlogLevel(entry.Severity)
+
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+
+	record := slog.NewRecord(entry.Timestamp, level, entry.Message, 0)
+
+	if caller := entry.Caller.String(); caller != "" {
+		record.AddAttrs(slog.String("caller", caller))
+	}
+
+	record.AddAttrs(toAttrs(entry.Tags)...)
+
+	if entry.Stack != "" {
+		record.AddAttrs(slog.String("stack", entry.Stack))
+	}
+
+	_ = l.handler.Handle(ctx, record)
+}
+
+func toAttrs(tags map[string]any) []slog.Attr {
+	ret := make([]slog.Attr, 0, len(tags))
+
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if group, ok := tags[key].(log.Group); ok {
+			ret = append(ret, slog.Attr{Key: key, Value: slog.GroupValue(toAttrs(group)...)})
+			continue
+		}
+
+		ret = append(ret, slog.Any(key, tags[key]))
+	}
+
+	return ret
+}
+
+func (l *logger) PreLog() func() {
+	return nil
+}
+
+func toSlogLevel(sev log.Severity) slog.Level {
+	switch sev {
+	case log.Debug:
+		return slog.LevelDebug
+	case log.Info:
+		return slog.LevelInfo
+	case log.Warn:
+		return slog.LevelWarn
+	case log.Error:
+		return slog.LevelError
+	}
+
+	return slog.LevelInfo
+}
@@ -0,0 +1,57 @@
+package slogtarget_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/log/slogtarget"
+)
+
+func TestNewLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	slogger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	logger := slogtarget.NewLogger(slogger, log.WithPrefix("Test"))
+
+	logger.D(func(lg log.DebugFn) {
+		lg("Debug message", log.WithInt("n", 1), log.WithError(io.EOF))
+	})
+
+	var record map[string]any
+
+	err := json.Unmarshal(buf.Bytes(), &record)
+	if err != nil {
+		t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+	}
+
+	want := map[string]any{
+		"level": "DEBUG",
+		"msg":   "Test: Debug message",
+		"n":     float64(1),
+		"error": "EOF",
+	}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("Field %s: want %v, have %v", k, v, record[k])
+		}
+	}
+}
+
+func TestDebugDisabled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slogtarget.NewLogger(slog.New(slog.NewTextHandler(buf, nil)))
+
+	logger.D(func(log.DebugFn) {
+		t.Error("Debug messages must not be computed when slog has debug disabled")
+	})
+
+	logger.I("Info message")
+
+	if buf.Len() == 0 {
+		t.Error("Info message was not logged")
+	}
+}
@@ -38,11 +38,16 @@ func (t testDriver) Send(l *log.Entry) {
 
 	fmt.Fprintf(msg, "[%s] %s", l.Severity, l.Message)
 
-	keys := slices.Collect(maps.Keys(l.Tags))
+	tags := log.Flatten(l.Tags)
+	keys := slices.Collect(maps.Keys(tags))
 	slices.Sort(keys)
 
 	for _, key := range keys {
-		fmt.Fprintf(msg, "\n- %s: %v", key, format(l.Tags[key]))
+		fmt.Fprintf(msg, "\n- %s: %v", key, format(tags[key]))
+	}
+
+	if l.Stack != "" {
+		fmt.Fprintf(msg, "\n%s", l.Stack)
 	}
 
 	logf := t.test.Log
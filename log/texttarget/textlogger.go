@@ -10,6 +10,7 @@ import (
 	"io"
 	"maps"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -51,9 +52,15 @@ func (l *logger) Send(entry *log.Entry) {
 		mux = l.errMux
 	}
 
-	msg := fmt.Sprintf("%s [%s] %s",
+	caller := ""
+	if c := entry.Caller.String(); c != "" {
+		caller = " " + c + ":"
+	}
+
+	msg := fmt.Sprintf("%s [%s]%s %s",
 		entry.Timestamp.Format(time.RFC3339Nano),
 		entry.Severity,
+		caller,
 		entry.Message)
 
 	switch entry.Severity {
@@ -65,21 +72,31 @@ func (l *logger) Send(entry *log.Entry) {
 		msg = color.New(color.FgRed).Sprint(msg)
 	}
 
-	fmt.Fprint(w, msg)
+	line := &strings.Builder{}
+	line.WriteString(msg)
 
-	keys := slices.Collect(maps.Keys(entry.Tags))
+	tags := log.Flatten(entry.Tags)
+	keys := slices.Collect(maps.Keys(tags))
 	slices.Sort(keys)
 
 	for _, key := range keys {
-		val := entry.Tags[key]
+		val := tags[key]
+
+		line.WriteString(color.New(color.FgMagenta).Sprintf(" %s=%v", key, val))
+	}
+
+	line.WriteString("\n")
 
-		fmt.Fprint(w, color.New(color.FgMagenta).Sprintf(" %s=%v", key, val))
+	if entry.Stack != "" {
+		line.WriteString(entry.Stack)
 	}
 
+	// the entry is written with a single call, so entries logged
+	// concurrently are not interleaved
 	mux.Lock()
 	defer mux.Unlock()
 
-	fmt.Fprintln(w)
+	_, _ = io.WriteString(w, line.String())
 }
 
 func (l *logger) PreLog() func() {
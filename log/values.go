@@ -0,0 +1,44 @@
+package log
+
+import "maps"
+
+// Group is a tag value that holds a namespace of other tags, created with
+// WithGroup.
+type Group map[string]any
+
+type lazyValue func() any
+
+// resolveLazy replaces values created with WithLazy by their result.
+func resolveLazy(tags map[string]any) {
+	for k, v := range tags {
+		switch vt := v.(type) {
+		case lazyValue:
+			tags[k] = vt()
+		case Group:
+			group := maps.Clone(vt)
+			resolveLazy(group)
+			tags[k] = group
+		}
+	}
+}
+
+// Flatten returns the tags with the values of groups moved to the top
+// level, with their keys prefixed by the name of the group and a dot. It is
+// useful for drivers that can't encode nested values.
+func Flatten(tags map[string]any) map[string]any {
+	ret := make(map[string]any, len(tags))
+	flatten(ret, "", tags)
+
+	return ret
+}
+
+func flatten(dst map[string]any, prefix string, tags map[string]any) {
+	for k, v := range tags {
+		if group, ok := v.(Group); ok {
+			flatten(dst, prefix+k+".", group)
+			continue
+		}
+
+		dst[prefix+k] = v
+	}
+}
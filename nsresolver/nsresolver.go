@@ -0,0 +1,43 @@
+// Package nsresolver sends DNS queries directly to a specific nameserver,
+// like the authoritative nameservers CloudFlare assigns to a zone, instead
+// of using the resolver configured on the system. This allows checking if
+// a change was already applied, without waiting for caches to expire.
+package nsresolver
+
+import (
+	"context"
+	"net"
+	"net/netip"
+)
+
+// Resolver looks up DNS records. It is implemented by *net.Resolver, and
+// can be replaced on tests.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// NewFunc creates a resolver that sends queries to the nameserver.
+type NewFunc func(nameserver string) Resolver
+
+// New creates a resolver that sends all queries to the nameserver, that
+// can be a hostname or an IP address, optionally with a port. The default
+// port is 53. Queries are sent over UDP, and over TCP when the response is
+// too large.
+func New(nameserver string) Resolver {
+	addr := nameserver
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		addr = net.JoinHostPort(nameserver, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+var _ NewFunc = New
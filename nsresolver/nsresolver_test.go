@@ -0,0 +1,52 @@
+package nsresolver_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns/internal/dnsstub"
+	"github.com/simplesurance/cfdns/nsresolver"
+)
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	stub, err := dnsstub.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stub.Close()
+
+	stub.Set("_acme-challenge.example.com", "TXT", "challenge-value")
+	stub.Set("www.example.com", "A", "192.0.2.1", "192.0.2.2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resolver := nsresolver.New(stub.Addr())
+
+	txt, err := resolver.LookupTXT(ctx, "_acme-challenge.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(txt, []string{"challenge-value"}) {
+		t.Errorf("Unexpected TXT records: %v", txt)
+	}
+
+	addrs, err := resolver.LookupNetIP(ctx, "ip4", "www.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(addrs) != 2 {
+		t.Errorf("Expected 2 addresses, got %v", addrs)
+	}
+
+	_, err = resolver.LookupTXT(ctx, "missing.example.com")
+	if err == nil {
+		t.Error("Expected an error for a missing record")
+	}
+}
@@ -1,6 +1,7 @@
 package cfdns
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -12,12 +13,25 @@ import (
 
 type Option func(*settings)
 
+// Limiter limits how fast requests are sent to CloudFlare. It is
+// implemented by *rate.Limiter and by the limiters on the ratelimit
+// package, which allow multiple processes to share the same quota.
+type Limiter interface {
+	// Wait blocks until a request can be sent. It must return an error
+	// if the context is done before that.
+	Wait(ctx context.Context) error
+}
+
 type settings struct {
-	ratelim        *rate.Limiter
+	ratelim        Limiter
 	logger         *log.Logger
 	httpClient     *http.Client
 	logSuccess     bool
 	requestTimeout time.Duration
+	circuitBreaker *CircuitBreakerSettings
+	interceptors   []Interceptor
+	redaction      Redaction
+	listPrefetch   int
 }
 
 func applyOptions(opts ...Option) *settings {
@@ -26,6 +40,7 @@ func applyOptions(opts ...Option) *settings {
 		logger:         log.New(niltarget.New()), // by default log messages are suppressed
 		httpClient:     http.DefaultClient,
 		requestTimeout: 30 * time.Second,
+		redaction:      DefaultRedaction(),
 	}
 	for _, opt := range opts {
 		opt(&ret)
@@ -40,6 +55,15 @@ func WithRateLimiter(ratelim *rate.Limiter) Option {
 	}
 }
 
+// WithLimiter configures the limiter that is consulted before each
+// request sent to CloudFlare, including retries. The default limits
+// requests to 1000 every 5 minutes for each Client.
+func WithLimiter(limiter Limiter) Option {
+	return func(s *settings) {
+		s.ratelim = limiter
+	}
+}
+
 func WithLogger(logger *log.Logger) Option {
 	return func(s *settings) {
 		s.logger = logger
@@ -72,3 +96,45 @@ func WithLogSuccessfulResponses(enable bool) Option {
 		s.logSuccess = enable
 	}
 }
+
+// WithRedaction configures how requests and responses are redacted when
+// logged with debug log enabled. It replaces the default redaction,
+// DefaultRedaction, so it should usually be extended instead:
+//
+//	redaction := cfdns.DefaultRedaction()
+//	redaction.TXTContent = true
+//	redaction.MaxBodyLength = 4096
+//
+//	client := cfdns.NewClient(creds, cfdns.WithRedaction(redaction))
+func WithRedaction(redaction Redaction) Option {
+	return func(s *settings) {
+		s.redaction = redaction
+	}
+}
+
+// WithCircuitBreaker enables a circuit breaker around requests sent to
+// CloudFlare. After too many consecutive retryable failures, requests fail
+// immediately with an error matching ErrCircuitOpen, instead of waiting
+// for retries that are likely to fail. State changes are logged and
+// reported to CircuitBreakerSettings.OnStateChange.
+//
+// The circuit breaker is disabled by default.
+func WithCircuitBreaker(cfg CircuitBreakerSettings) Option {
+	return func(s *settings) {
+		s.circuitBreaker = &cfg
+	}
+}
+
+// WithListPrefetch makes iterators returned by ListRecords and ListZones
+// fetch up to pages pages in the background while the current one is
+// consumed. After the first page is read, and the total amount of items is
+// known, the pages are fetched concurrently. Items are still returned in
+// order, and at most pages+1 pages are kept in memory. Requests are still
+// subject to the rate limiter.
+//
+// Prefetching is disabled by default.
+func WithListPrefetch(pages int) Option {
+	return func(s *settings) {
+		s.listPrefetch = pages
+	}
+}
@@ -0,0 +1,246 @@
+// Package otelcfdns instruments cfdns.Client with OpenTelemetry.
+//
+// A span is created for each operation executed by the client, with a child
+// span for each attempt sent to CloudFlare. Metrics are recorded about the
+// number of requests, their latency, retries and time spent waiting for
+// the rate limiter.
+//
+// Usage:
+//
+//	interceptor, err := otelcfdns.NewInterceptor()
+//	if err != nil {
+//		return err
+//	}
+//
+//	client := cfdns.NewClient(creds, cfdns.WithInterceptors(interceptor))
+package otelcfdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/simplesurance/cfdns"
+)
+
+const instrumentationName = "github.com/simplesurance/cfdns/otelcfdns"
+
+// Attribute keys set on spans and metrics. AttrZoneID is only set on
+// spans, to not create metric series for each zone.
+const (
+	AttrOperation    = attribute.Key("cfdns.operation")
+	AttrZoneID       = attribute.Key("cfdns.zone_id")
+	AttrRecordType   = attribute.Key("cfdns.record_type")
+	AttrAttempt      = attribute.Key("cfdns.attempt")
+	AttrCFErrorCodes = attribute.Key("cfdns.cloudflare.error_codes")
+	AttrHTTPMethod   = attribute.Key("http.request.method")
+	AttrHTTPStatus   = attribute.Key("http.response.status_code")
+)
+
+// NewInterceptor creates an interceptor that sends traces and metrics to
+// OpenTelemetry. By default the global tracer and meter providers are used.
+func NewInterceptor(opts ...Option) (cfdns.Interceptor, error) {
+	s := applyOptions(opts...)
+
+	ins := &instrumentation{
+		tracer: s.tracerProvider.Tracer(instrumentationName),
+	}
+
+	err := ins.createInstruments(s.meterProvider.Meter(instrumentationName))
+	if err != nil {
+		return cfdns.Interceptor{}, err
+	}
+
+	return cfdns.Interceptor{
+		Operation: ins.interceptOperation,
+		Attempt:   ins.interceptAttempt,
+	}, nil
+}
+
+type instrumentation struct {
+	tracer trace.Tracer
+
+	requests        metric.Int64Counter
+	retries         metric.Int64Counter
+	opDuration      metric.Float64Histogram
+	attemptDuration metric.Float64Histogram
+	limiterWait     metric.Float64Histogram
+}
+
+func (ins *instrumentation) createInstruments(meter metric.Meter) error {
+	var err, errs error
+
+	ins.requests, err = meter.Int64Counter("cfdns.client.requests",
+		metric.WithDescription("HTTP requests sent to CloudFlare"),
+		metric.WithUnit("{request}"))
+	errs = errors.Join(errs, err)
+
+	ins.retries, err = meter.Int64Counter("cfdns.client.retries",
+		metric.WithDescription("Requests sent again after a failure"),
+		metric.WithUnit("{request}"))
+	errs = errors.Join(errs, err)
+
+	ins.opDuration, err = meter.Float64Histogram("cfdns.client.operation.duration",
+		metric.WithDescription("Duration of operations, including retries and rate limiting"),
+		metric.WithUnit("s"))
+	errs = errors.Join(errs, err)
+
+	ins.attemptDuration, err = meter.Float64Histogram("cfdns.client.request.duration",
+		metric.WithDescription("Duration of HTTP requests sent to CloudFlare"),
+		metric.WithUnit("s"))
+	errs = errors.Join(errs, err)
+
+	ins.limiterWait, err = meter.Float64Histogram("cfdns.client.rate_limiter.wait",
+		metric.WithDescription("Time requests waited for the client-side rate limiter"),
+		metric.WithUnit("s"))
+	errs = errors.Join(errs, err)
+
+	return errs
+}
+
+func (ins *instrumentation) interceptOperation(
+	ctx context.Context,
+	op *cfdns.Operation,
+	next func(context.Context) error,
+) error {
+	attrs := operationAttributes(op)
+
+	ctx, span := ins.tracer.Start(ctx, op.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(spanAttributes(op)...))
+	defer span.End()
+
+	start := time.Now()
+	err := next(ctx)
+
+	if op.Attempts > 1 {
+		ins.retries.Add(ctx, int64(op.Attempts-1), metric.WithAttributes(attrs...))
+	}
+
+	ins.opDuration.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(append(attrs, errorAttributes(err)...)...))
+
+	setSpanStatus(span, err)
+
+	return err
+}
+
+func (ins *instrumentation) interceptAttempt(
+	ctx context.Context,
+	att *cfdns.Attempt,
+	next func(context.Context) error,
+) error {
+	attrs := operationAttributes(att.Operation)
+
+	ins.limiterWait.Record(ctx, att.LimiterWait.Seconds(), metric.WithAttributes(attrs...))
+
+	ctx, span := ins.tracer.Start(ctx, fmt.Sprintf("%s attempt", att.Operation.Name),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(spanAttributes(att.Operation)...),
+		trace.WithAttributes(AttrAttempt.Int(att.Number)))
+	defer span.End()
+
+	start := time.Now()
+	err := next(ctx)
+
+	resultAttrs := errorAttributes(err)
+	if att.Response != nil {
+		resultAttrs = append(resultAttrs, AttrHTTPStatus.Int(att.Response.StatusCode))
+	}
+
+	span.SetAttributes(resultAttrs...)
+
+	attrs = append(attrs, resultAttrs...)
+	ins.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	ins.attemptDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+	setSpanStatus(span, err)
+
+	return err
+}
+
+// operationAttributes returns the attributes of the operation set on both
+// spans and metrics.
+func operationAttributes(op *cfdns.Operation) []attribute.KeyValue {
+	ret := []attribute.KeyValue{
+		AttrOperation.String(op.Name),
+		AttrHTTPMethod.String(op.Method),
+	}
+
+	if op.RecordType != "" {
+		ret = append(ret, AttrRecordType.String(op.RecordType))
+	}
+
+	return ret
+}
+
+// spanAttributes returns the attributes of the operation that are only
+// set on spans, because they have too many distinct values for metrics.
+func spanAttributes(op *cfdns.Operation) []attribute.KeyValue {
+	if op.ZoneID == "" {
+		return nil
+	}
+
+	return []attribute.KeyValue{AttrZoneID.String(op.ZoneID)}
+}
+
+func errorAttributes(err error) []attribute.KeyValue {
+	var cfErr cfdns.CloudFlareError
+	if !errors.As(err, &cfErr) {
+		return nil
+	}
+
+	return []attribute.KeyValue{AttrCFErrorCodes.IntSlice(cfErr.Codes())}
+}
+
+func setSpanStatus(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Option configures the interceptor created with NewInterceptor.
+type Option func(*settings)
+
+type settings struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+func applyOptions(opts ...Option) *settings {
+	ret := settings{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&ret)
+	}
+
+	return &ret
+}
+
+// WithTracerProvider configures the tracer provider used to create spans.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *settings) {
+		s.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider configures the meter provider used to record metrics.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(s *settings) {
+		s.meterProvider = mp
+	}
+}
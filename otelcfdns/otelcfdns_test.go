@@ -0,0 +1,123 @@
+package otelcfdns_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+	"github.com/simplesurance/cfdns/otelcfdns"
+)
+
+func TestInterceptor(t *testing.T) {
+	ctx := context.Background()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	interceptor, err := otelcfdns.NewInterceptor(
+		otelcfdns.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		otelcfdns.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := fakecf.NewClient(t, fakecf.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return fakecf.JSONResponse(http.StatusBadRequest,
+			`{"success":false,"errors":[{"code":9005,"message":"Content for A record must be a valid IPv4 address."}]}`), nil
+	}), cfdns.WithInterceptors(interceptor))
+
+	_, err = client.CreateRecord(ctx, &cfdns.CreateRecordRequest{
+		ZoneID:  "zone-id",
+		Name:    "rec.example.com",
+		Type:    "A",
+		Content: "github.com",
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(ended))
+	}
+
+	attempt, op := ended[0], ended[1]
+
+	if op.Name() != "CreateDNSRecord" || attempt.Parent().SpanID() != op.SpanContext().SpanID() {
+		t.Errorf("Expected attempt span to be a child of the operation span")
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range attempt.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	for k, want := range map[string]string{
+		"cfdns.zone_id":                "zone-id",
+		"cfdns.record_type":            "A",
+		"cfdns.attempt":                "1",
+		"http.response.status_code":    "400",
+		"cfdns.cloudflare.error_codes": "[9005]",
+	} {
+		if attrs[k] != want {
+			t.Errorf("Attribute %s: want %q, have %q", k, want, attrs[k])
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+
+	err = reader.Collect(ctx, &rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = true
+
+			for _, set := range metricAttributeSets(m) {
+				if set.HasValue(otelcfdns.AttrZoneID) {
+					t.Errorf("Metric %s has the zone ID attribute", m.Name)
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{
+		"cfdns.client.requests",
+		"cfdns.client.operation.duration",
+		"cfdns.client.request.duration",
+		"cfdns.client.rate_limiter.wait",
+	} {
+		if !found[name] {
+			t.Errorf("Metric %s was not recorded", name)
+		}
+	}
+}
+
+func metricAttributeSets(m metricdata.Metrics) []attribute.Set {
+	var ret []attribute.Set
+
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		for _, dp := range data.DataPoints {
+			ret = append(ret, dp.Attributes)
+		}
+	case metricdata.Histogram[float64]:
+		for _, dp := range data.DataPoints {
+			ret = append(ret, dp.Attributes)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,179 @@
+package cfdns
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Priority defines the order in which requests waiting for the rate limiter
+// are sent to CloudFlare. Requests with higher priority are sent first.
+// Requests with the same priority are sent in the order they were made.
+type Priority int
+
+const (
+	// PriorityLow is meant for bulk operations, like listing all records
+	// of a zone, that can wait for more urgent requests.
+	PriorityLow Priority = iota - 1
+
+	// PriorityNormal is the priority of requests when none is specified.
+	PriorityNormal
+
+	// PriorityHigh is meant for latency-sensitive requests, like creating
+	// a DNS record for an ACME DNS-01 challenge.
+	PriorityHigh
+)
+
+type priorityCtxKey struct{}
+
+// ContextWithPriority returns a context that makes requests sent with it
+// use the provided priority when waiting for the rate limiter.
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, p)
+}
+
+// PriorityFromContext returns the priority set on the context with
+// ContextWithPriority, or PriorityNormal if none was set.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, ok := ctx.Value(priorityCtxKey{}).(Priority)
+	if !ok {
+		return PriorityNormal
+	}
+
+	return p
+}
+
+// priorityLimiter hands tokens from a Limiter to waiting requests according
+// to their priority. Only one goroutine waits on the underlying limiter at
+// a time; once it gets a token it is given to the request with the highest
+// priority waiting at that moment.
+type priorityLimiter struct {
+	limiter Limiter
+
+	mu         sync.Mutex
+	waiters    waiterQueue
+	seq        uint64
+	running    bool
+	cancelWait func()
+}
+
+func newPriorityLimiter(limiter Limiter) *priorityLimiter {
+	return &priorityLimiter{limiter: limiter}
+}
+
+func (p *priorityLimiter) Wait(ctx context.Context) error {
+	w := &waiter{
+		priority: PriorityFromContext(ctx),
+		granted:  make(chan error, 1),
+	}
+
+	p.mu.Lock()
+	p.seq++
+	w.seq = p.seq
+	heap.Push(&p.waiters, w)
+
+	if !p.running {
+		p.running = true
+
+		go p.dispatch()
+	}
+	p.mu.Unlock()
+
+	select {
+	case err := <-w.granted:
+		return err
+	case <-ctx.Done():
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		// if the waiter was already removed from the queue the token was
+		// granted to it, and is lost
+		if w.index >= 0 {
+			heap.Remove(&p.waiters, w.index)
+
+			if p.waiters.Len() == 0 && p.cancelWait != nil {
+				p.cancelWait()
+			}
+		}
+
+		return ctx.Err()
+	}
+}
+
+func (p *priorityLimiter) dispatch() {
+	for {
+		p.mu.Lock()
+		if p.waiters.Len() == 0 {
+			p.running = false
+			p.mu.Unlock()
+
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		p.cancelWait = cancel
+		p.mu.Unlock()
+
+		err := p.limiter.Wait(ctx)
+		aborted := err != nil && ctx.Err() != nil
+		cancel()
+
+		p.mu.Lock()
+		p.cancelWait = nil
+
+		if aborted || p.waiters.Len() == 0 {
+			// all waiters gave up; check again if there is a new one
+			p.mu.Unlock()
+			continue
+		}
+
+		w := heap.Pop(&p.waiters).(*waiter) //nolint:forcetypeassert // only *waiter is pushed
+		p.mu.Unlock()
+
+		w.granted <- err
+	}
+}
+
+type waiter struct {
+	priority Priority
+	seq      uint64
+	index    int
+	granted  chan error
+}
+
+// waiterQueue implements heap.Interface, with the waiter with the highest
+// priority at the top.
+type waiterQueue []*waiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x any) {
+	w := x.(*waiter) //nolint:forcetypeassert // only *waiter is pushed
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiterQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+
+	return w
+}
@@ -0,0 +1,103 @@
+package cfdns
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// gateLimiter is a Limiter that grants one token for each value sent to
+// the tokens channel.
+type gateLimiter struct {
+	tokens chan struct{}
+}
+
+func (g *gateLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-g.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestPriorityLimiter(t *testing.T) {
+	gate := &gateLimiter{tokens: make(chan struct{})}
+	lim := newPriorityLimiter(gate)
+	order := make(chan string, 10)
+
+	wait := func(name string, p Priority) {
+		err := lim.Wait(ContextWithPriority(context.Background(), p))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		order <- name
+	}
+
+	go wait("low1", PriorityLow)
+	waitForQueueLen(t, lim, 1)
+
+	go wait("low2", PriorityLow)
+	waitForQueueLen(t, lim, 2)
+
+	go wait("normal", PriorityNormal)
+	waitForQueueLen(t, lim, 3)
+
+	go wait("high", PriorityHigh)
+	waitForQueueLen(t, lim, 4)
+
+	for _, want := range []string{"high", "normal", "low1", "low2"} {
+		gate.tokens <- struct{}{}
+
+		if have := <-order; have != want {
+			t.Fatalf("Expected %s to get the token, got %s", want, have)
+		}
+	}
+}
+
+func TestPriorityLimiterCanceledWaiter(t *testing.T) {
+	gate := &gateLimiter{tokens: make(chan struct{})}
+	lim := newPriorityLimiter(gate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := lim.Wait(ctx)
+	if err == nil {
+		t.Fatal("Expected the wait to fail when the context expires")
+	}
+
+	waitForQueueLen(t, lim, 0)
+
+	// the next waiter must still get a token
+	done := make(chan error)
+
+	go func() {
+		done <- lim.Wait(context.Background())
+	}()
+
+	gate.tokens <- struct{}{}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func waitForQueueLen(t *testing.T, lim *priorityLimiter, want int) {
+	t.Helper()
+
+	for range 1000 {
+		lim.mu.Lock()
+		have := lim.waiters.Len()
+		lim.mu.Unlock()
+
+		if have == want {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("Timeout waiting for %d waiters", want)
+}
@@ -0,0 +1,184 @@
+// Package promcfdns exposes metrics about the activity of cfdns.Client as
+// a Prometheus collector.
+//
+// Usage:
+//
+//	collector := promcfdns.NewCollector()
+//	prometheus.MustRegister(collector)
+//
+//	client := cfdns.NewClient(creds, collector.Option())
+//
+// The same collector can be attached to multiple clients.
+package promcfdns
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/simplesurance/cfdns"
+)
+
+const namespace = "cfdns"
+
+// Collector is a prometheus.Collector with metrics about requests sent to
+// CloudFlare by the clients it is attached to.
+type Collector struct {
+	requests          *prometheus.CounterVec
+	cfErrors          *prometheus.CounterVec
+	retries           *prometheus.CounterVec
+	permanentFailures *prometheus.CounterVec
+	aborted           *prometheus.CounterVec
+	requestDuration   *prometheus.HistogramVec
+	limiterWait       *prometheus.HistogramVec
+	responseSize      *prometheus.HistogramVec
+}
+
+// NewCollector creates a collector. It must be registered on a Prometheus
+// registry, and attached to clients with Option.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "HTTP requests sent to CloudFlare, by status class. The status class is \"error\" when no response was received.",
+		}, []string{"operation", "method", "status_class"}),
+
+		cfErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cloudflare_errors_total",
+			Help:      "Errors returned by CloudFlare, by CloudFlare error code.",
+		}, []string{"operation", "code"}),
+
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Requests sent again after a failure.",
+		}, []string{"operation"}),
+
+		permanentFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "permanent_failures_total",
+			Help:      "Operations that failed, after retrying when possible. Operations aborted by the caller or by the circuit breaker are not included.",
+		}, []string{"operation"}),
+
+		aborted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "aborted_operations_total",
+			Help:      "Operations aborted before completing, by reason: \"canceled\" when the context of the caller was done, \"circuit_open\" when rejected by the circuit breaker.",
+		}, []string{"operation", "reason"}),
+
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests sent to CloudFlare.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+
+		limiterWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "Time requests waited for the client-side rate limiter.",
+			Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		}, []string{"operation"}),
+
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "response_size_bytes",
+			Help:      "Size of response bodies received from CloudFlare.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"operation"}),
+	}
+}
+
+// Option returns a cfdns option that attaches the collector to a client.
+func (c *Collector) Option() cfdns.Option {
+	return cfdns.WithInterceptors(cfdns.Interceptor{
+		Operation: c.interceptOperation,
+		Attempt:   c.interceptAttempt,
+	})
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, col := range c.collectors() {
+		col.Describe(ch)
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, col := range c.collectors() {
+		col.Collect(ch)
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests,
+		c.cfErrors,
+		c.retries,
+		c.permanentFailures,
+		c.aborted,
+		c.requestDuration,
+		c.limiterWait,
+		c.responseSize,
+	}
+}
+
+func (c *Collector) interceptOperation(
+	ctx context.Context,
+	op *cfdns.Operation,
+	next func(context.Context) error,
+) error {
+	err := next(ctx)
+
+	if op.Attempts > 1 {
+		c.retries.WithLabelValues(op.Name).Add(float64(op.Attempts - 1))
+	}
+
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		c.aborted.WithLabelValues(op.Name, "canceled").Inc()
+	case errors.Is(err, cfdns.ErrCircuitOpen):
+		c.aborted.WithLabelValues(op.Name, "circuit_open").Inc()
+	default:
+		c.permanentFailures.WithLabelValues(op.Name).Inc()
+	}
+
+	return err
+}
+
+func (c *Collector) interceptAttempt(
+	ctx context.Context,
+	att *cfdns.Attempt,
+	next func(context.Context) error,
+) error {
+	op := att.Operation.Name
+
+	c.limiterWait.WithLabelValues(op).Observe(att.LimiterWait.Seconds())
+
+	err := next(ctx)
+
+	statusClass := "error"
+	if att.Response != nil {
+		statusClass = strconv.Itoa(att.Response.StatusCode/100) + "xx"
+
+		c.responseSize.WithLabelValues(op).Observe(float64(att.ResponseSize))
+	}
+
+	c.requests.WithLabelValues(op, att.Operation.Method, statusClass).Inc()
+	c.requestDuration.WithLabelValues(op).Observe(att.Latency.Seconds())
+
+	var cfErr cfdns.CloudFlareError
+	if errors.As(err, &cfErr) {
+		for _, code := range cfErr.Codes() {
+			c.cfErrors.WithLabelValues(op, strconv.Itoa(code)).Inc()
+		}
+	}
+
+	return err
+}
+
+var _ prometheus.Collector = &Collector{}
@@ -0,0 +1,122 @@
+package promcfdns_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+	"github.com/simplesurance/cfdns/promcfdns"
+)
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+
+	collector := promcfdns.NewCollector()
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(collector)
+
+	client := fakecf.NewClient(t, fakecf.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodDelete {
+			return fakecf.JSONResponse(http.StatusOK, `{"success":true,"result":{}}`), nil
+		}
+
+		return fakecf.JSONResponse(http.StatusBadRequest,
+			`{"success":false,"errors":[{"code":9005,"message":"Content for A record must be a valid IPv4 address."}]}`), nil
+	}), collector.Option())
+
+	_, err := client.CreateRecord(ctx, &cfdns.CreateRecordRequest{
+		ZoneID:  "zone-id",
+		Name:    "rec.example.com",
+		Type:    "A",
+		Content: "github.com",
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	_, err = client.DeleteRecord(ctx, &cfdns.DeleteRecordRequest{
+		ZoneID:   "zone-id",
+		RecordID: "rec-id",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP cfdns_cloudflare_errors_total Errors returned by CloudFlare, by CloudFlare error code.
+# TYPE cfdns_cloudflare_errors_total counter
+cfdns_cloudflare_errors_total{code="9005",operation="CreateDNSRecord"} 1
+# HELP cfdns_permanent_failures_total Operations that failed, after retrying when possible. Operations aborted by the caller or by the circuit breaker are not included.
+# TYPE cfdns_permanent_failures_total counter
+cfdns_permanent_failures_total{operation="CreateDNSRecord"} 1
+# HELP cfdns_requests_total HTTP requests sent to CloudFlare, by status class. The status class is "error" when no response was received.
+# TYPE cfdns_requests_total counter
+cfdns_requests_total{method="DELETE",operation="DeleteDNSRecord",status_class="2xx"} 1
+cfdns_requests_total{method="POST",operation="CreateDNSRecord",status_class="4xx"} 1
+`),
+		"cfdns_cloudflare_errors_total",
+		"cfdns_permanent_failures_total",
+		"cfdns_requests_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(collector, "cfdns_response_size_bytes"); n != 2 {
+		t.Errorf("Expected response sizes for 2 operations, got %d", n)
+	}
+}
+
+func TestCollectorAbortedOperations(t *testing.T) {
+	ctx := context.Background()
+
+	collector := promcfdns.NewCollector()
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(collector)
+
+	client := fakecf.NewClient(t, fakecf.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return fakecf.JSONResponse(http.StatusServiceUnavailable, `{"success":false}`), nil
+	}),
+		collector.Option(),
+		cfdns.WithCircuitBreaker(cfdns.CircuitBreakerSettings{FailureThreshold: 1}))
+
+	deleteRecord := func(ctx context.Context) {
+		_, err := client.DeleteRecord(ctx, &cfdns.DeleteRecordRequest{
+			ZoneID:   "zone-id",
+			RecordID: "rec-id",
+		})
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+	}
+
+	// the failure opens the circuit, rejecting the next operation
+	deleteRecord(ctx)
+	deleteRecord(ctx)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	deleteRecord(canceledCtx)
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP cfdns_aborted_operations_total Operations aborted before completing, by reason: "canceled" when the context of the caller was done, "circuit_open" when rejected by the circuit breaker.
+# TYPE cfdns_aborted_operations_total counter
+cfdns_aborted_operations_total{operation="DeleteDNSRecord",reason="canceled"} 1
+cfdns_aborted_operations_total{operation="DeleteDNSRecord",reason="circuit_open"} 1
+# HELP cfdns_permanent_failures_total Operations that failed, after retrying when possible. Operations aborted by the caller or by the circuit breaker are not included.
+# TYPE cfdns_permanent_failures_total counter
+cfdns_permanent_failures_total{operation="DeleteDNSRecord"} 1
+`),
+		"cfdns_aborted_operations_total",
+		"cfdns_permanent_failures_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
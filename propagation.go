@@ -0,0 +1,193 @@
+package cfdns
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+	"github.com/simplesurance/cfdns/nsresolver"
+)
+
+const defaultPropagationPollInterval = 5 * time.Second
+
+// WaitForPropagation waits until the records with the name and type are
+// served by the authoritative nameservers of the zone, by querying them
+// directly, or until the context is done. It is useful to know when a
+// change is live, after CreateRecord or UpdateRecord returns.
+//
+// The records served must be exactly the values of the request, unless
+// Subset is set. An empty list of values waits until the records are
+// removed.
+//
+// For proxied records CloudFlare serves the addresses of its proxies, so
+// their values can't be checked.
+func (c *Client) WaitForPropagation(
+	ctx context.Context,
+	req *WaitForPropagationRequest,
+) error {
+	nameServers := req.NameServers
+	if len(nameServers) == 0 {
+		var err error
+
+		nameServers, err = c.zoneNameServers(ctx, req.ZoneID)
+		if err != nil {
+			return err
+		}
+
+		if len(nameServers) == 0 {
+			return fmt.Errorf("zone %s has no nameservers assigned", req.ZoneID)
+		}
+	}
+
+	newResolver := req.Resolver
+	if newResolver == nil {
+		newResolver = nsresolver.New
+	}
+
+	interval := cmp.Or(req.PollInterval, defaultPropagationPollInterval)
+	logger := c.contextLogger(ctx).SubLogger(log.WithPrefix("WaitForPropagation"))
+
+	for _, ns := range nameServers {
+		resolver := newResolver(ns)
+
+		for {
+			served, err := lookupRecords(ctx, resolver, req.Name, req.Type)
+			if err == nil && req.matches(served) {
+				break
+			}
+
+			logger.D(func(log log.DebugFn) {
+				log(fmt.Sprintf("%s %s not yet served by %s: have %v (%v)",
+					req.Name, req.Type, ns, served, err))
+			})
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for %s %s to be served by %s: %w",
+					req.Name, req.Type, ns, ctx.Err())
+			case <-time.After(interval):
+			}
+		}
+	}
+
+	logger.D(func(log log.DebugFn) {
+		log(fmt.Sprintf("%s %s served by %s", req.Name, req.Type, strings.Join(nameServers, ", ")))
+	})
+
+	return nil
+}
+
+type WaitForPropagationRequest struct {
+	ZoneID string
+	Name   string
+	Type   string // A, AAAA, CNAME or TXT
+	Values []string
+
+	// Subset makes the check succeed when all values are served, even if
+	// other values are also served, e.g., when other TXT records exist
+	// with the same name.
+	Subset bool
+
+	// NameServers to query. By default the nameservers assigned to the
+	// zone by CloudFlare are used.
+	NameServers []string
+
+	// Resolver creates the resolver used to query each nameserver. The
+	// default is nsresolver.New. It allows replacing CloudFlare with a
+	// local DNS server on tests.
+	Resolver nsresolver.NewFunc
+
+	// PollInterval is how long to wait between queries. The default is 5
+	// seconds.
+	PollInterval time.Duration
+}
+
+// matches returns true if the values served are the expected ones.
+func (req *WaitForPropagationRequest) matches(served []string) bool {
+	for _, want := range req.Values {
+		if !slices.ContainsFunc(served, func(have string) bool { return sameContent(req.Type, have, want) }) {
+			return false
+		}
+	}
+
+	return req.Subset || len(uniqueValues(req.Type, served)) == len(uniqueValues(req.Type, req.Values))
+}
+
+// lookupRecords returns the values of the records served by the resolver.
+// No records being found is not an error.
+func lookupRecords(ctx context.Context, resolver nsresolver.Resolver, name, typ string) ([]string, error) {
+	var (
+		ret []string
+		err error
+	)
+
+	switch strings.ToUpper(typ) {
+	case "A", "AAAA":
+		network := "ip4"
+		if strings.EqualFold(typ, "AAAA") {
+			network = "ip6"
+		}
+
+		addrs, lookupErr := resolver.LookupNetIP(ctx, network, name)
+		for _, addr := range addrs {
+			ret = append(ret, addr.Unmap().String())
+		}
+
+		err = lookupErr
+	case "CNAME":
+		var target string
+
+		target, err = resolver.LookupCNAME(ctx, name)
+		if err == nil && !strings.EqualFold(strings.TrimSuffix(target, "."), strings.TrimSuffix(name, ".")) {
+			ret = []string{target}
+		}
+	case "TXT":
+		ret, err = resolver.LookupTXT(ctx, name)
+	default:
+		return nil, fmt.Errorf("checking propagation of %s records is not supported", typ)
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+
+	return ret, err
+}
+
+// zoneNameServers returns the nameservers CloudFlare assigned to the zone.
+//
+// API Reference: https://developers.cloudflare.com/api/operations/zones-0-get
+func (c *Client) zoneNameServers(ctx context.Context, zoneID string) ([]string, error) {
+	resp, err := sendRequestRetry[*getZoneAPIResponse](
+		ctx,
+		c,
+		c.logger.SubLogger(log.WithPrefix("GetZone")),
+		&request{
+			operation:   "GetZone",
+			zoneID:      zoneID,
+			method:      http.MethodGet,
+			path:        "zones/" + url.PathEscape(zoneID),
+			queryParams: url.Values{},
+			body:        nil,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.body.Result.NameServers, nil
+}
+
+type getZoneAPIResponse struct {
+	cfResponseCommon
+
+	Result listZoneAPIResponseItem `json:"result"`
+}
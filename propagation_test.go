@@ -0,0 +1,104 @@
+package cfdns_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/dnsstub"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestWaitForPropagation(t *testing.T) {
+	t.Parallel()
+
+	stub, err := dnsstub.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stub.Close()
+
+	// the nameservers of the zone are looked up on CloudFlare
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com", stub.Addr())
+	client := fake.Client(t)
+
+	stub.Set("www.example.com", "A", "192.0.2.1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := &cfdns.WaitForPropagationRequest{
+		ZoneID:       zoneID,
+		Name:         "www.example.com",
+		Type:         "A",
+		Values:       []string{"192.0.2.2", "192.0.2.1"},
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	go func() {
+		for stub.Queries() < 3 {
+			time.Sleep(time.Millisecond)
+		}
+
+		stub.Set("www.example.com", "A", "192.0.2.1", "192.0.2.2")
+	}()
+
+	err = client.WaitForPropagation(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := stub.Queries(); n < 3 {
+		t.Errorf("Expected to wait for the change, but only %d queries were sent", n)
+	}
+
+	// waiting for the removal of the records
+	stub.Set("www.example.com", "A")
+
+	req.Values = nil
+
+	err = client.WaitForPropagation(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForPropagationTimeout(t *testing.T) {
+	t.Parallel()
+
+	stub, err := dnsstub.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stub.Close()
+
+	stub.Set("_acme-challenge.example.com", "TXT", "other", "value")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	client := fakecf.New().Client(t)
+	req := &cfdns.WaitForPropagationRequest{
+		Name:         "_acme-challenge.example.com",
+		Type:         "TXT",
+		Values:       []string{"value"},
+		NameServers:  []string{stub.Addr()},
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	// another value is also served
+	err = client.WaitForPropagation(ctx, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the context to expire, got %v", err)
+	}
+
+	req.Subset = true
+
+	err = client.WaitForPropagation(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+)
+
+// lockRetryInterval is how long to wait before trying again to lock the
+// file, while it is locked by another process.
+const lockRetryInterval = 10 * time.Millisecond
+
+// FileStore is a Store that keeps the state on a file, protected by a file
+// lock. It allows sharing the same quota among multiple processes on the
+// same machine.
+//
+// File locks are only supported on Linux, macOS and the BSDs. On other
+// platforms, like Windows, Reserve always fails with an error that matches
+// errors.ErrUnsupported.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store that keeps its state on the file on the
+// provided path. The file is created if it does not exist. All processes
+// sharing the quota must use the same path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Reserve reserves the next slot for the key. If another process holds
+// the lock on the file, it waits until the lock is released or the context
+// is done.
+func (f *FileStore) Reserve(ctx context.Context, key string, interval time.Duration, burst int) (time.Time, error) {
+	if !fileLockSupported {
+		return time.Time{}, fmt.Errorf("%w: rate limiter state can not be locked on %s",
+			errors.ErrUnsupported, runtime.GOOS)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("opening rate limiter state: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	err = lockFile(ctx, file)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("locking rate limiter state %s: %w", f.path, err)
+	}
+
+	defer func() {
+		_ = unlockFile(file)
+	}()
+
+	state, err := readFileState(file)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading rate limiter state %s: %w", f.path, err)
+	}
+
+	sendAt, tat := Next(time.Now(), state[key], interval, burst)
+	state[key] = tat
+
+	err = writeFileState(file, state)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("writing rate limiter state %s: %w", f.path, err)
+	}
+
+	return sendAt, nil
+}
+
+// lockFile acquires an exclusive lock on the file, waiting while it is
+// locked by another process until the context is done.
+func lockFile(ctx context.Context, file *os.File) error {
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil || locked {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func readFileState(file *os.File) (map[string]time.Time, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	state := map[string]time.Time{}
+	if len(data) == 0 {
+		return state, nil
+	}
+
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func writeFileState(file *os.File, state map[string]time.Time) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	err = file.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteAt(data, 0)
+
+	return err
+}
+
+var _ Store = &FileStore{}
@@ -0,0 +1,27 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns/ratelimit"
+)
+
+func TestFileStoreUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfdns.ratelimit")
+
+	_, err := ratelimit.NewFileStore(path).Reserve(context.Background(), "key", time.Second, 1)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected an error matching errors.ErrUnsupported, got %v", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the state file not to be created, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns/ratelimit"
+)
+
+func TestFileStoreLockRespectsContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfdns.ratelimit")
+
+	// another process holding the lock
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = ratelimit.NewFileStore(path).Reserve(ctx, "token", time.Second, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the context to expire while waiting for the lock, got %v", err)
+	}
+
+	// after the lock is released the reservation succeeds
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ratelimit.NewFileStore(path).Reserve(context.Background(), "token", time.Second, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package ratelimit
+
+import (
+	"errors"
+	"os"
+)
+
+const fileLockSupported = false
+
+func tryLockFile(_ *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func unlockFile(_ *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps the state in memory. It allows sharing
+// the same quota among multiple clients on the same process.
+type MemoryStore struct {
+	mu  sync.Mutex
+	tat map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tat: map[string]time.Time{}}
+}
+
+func (m *MemoryStore) Reserve(_ context.Context, key string, interval time.Duration, burst int) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sendAt, tat := Next(time.Now(), m.tat[key], interval, burst)
+	m.tat[key] = tat
+
+	return sendAt, nil
+}
+
+var _ Store = &MemoryStore{}
@@ -0,0 +1,95 @@
+// Package ratelimit implements request rate limiters that can be shared
+// by multiple clients, even when they are running on different processes.
+//
+// The limiters implement the cfdns.Limiter interface and can be used with
+// cfdns.WithLimiter. The state of the limiter is kept on a Store, allowing
+// all processes that use the same API token to coordinate, respecting
+// together the request quota from CloudFlare.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps the state of shared rate limiters.
+//
+// The algorithm used is GCRA (generic cell rate algorithm). The only state
+// kept for each key is the "theoretical arrival time" (TAT) of the next
+// request. This allows implementing it on top of any store that supports
+// atomically reading and updating a single value, like a file protected
+// by a lock or a Redis key updated by a Lua script.
+type Store interface {
+	// Reserve reserves a slot for sending one request, and returns the time
+	// at which the request can be sent. The reservation can't be undone.
+	//
+	// Implementations must atomically apply the function Next to the state
+	// stored under key, and must be safe for concurrent use.
+	Reserve(ctx context.Context, key string, interval time.Duration, burst int) (time.Time, error)
+}
+
+// Next computes a reservation according to GCRA. tat is the current
+// theoretical arrival time stored for the key; the zero time must be used if
+// there is no value stored yet. It returns the time at which the request can
+// be sent and the new value that must be stored.
+//
+// It is exported to allow implementing Store on other backends.
+func Next(now, tat time.Time, interval time.Duration, burst int) (sendAt, newTAT time.Time) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	if tat.Before(now) {
+		tat = now
+	}
+
+	sendAt = tat.Add(-time.Duration(burst-1) * interval)
+	if sendAt.Before(now) {
+		sendAt = now
+	}
+
+	return sendAt, tat.Add(interval)
+}
+
+// Limiter is a rate limiter whose state is kept on a Store.
+type Limiter struct {
+	store    Store
+	key      string
+	interval time.Duration
+	burst    int
+}
+
+// New creates a limiter that allows one request every interval, allowing
+// bursts of up to burst requests. All limiters with the same key on the same
+// store share the same quota, so they must use the same interval and burst.
+func New(store Store, key string, interval time.Duration, burst int) *Limiter {
+	return &Limiter{
+		store:    store,
+		key:      key,
+		interval: interval,
+		burst:    burst,
+	}
+}
+
+// Wait blocks until a request can be sent or the context is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	sendAt, err := l.store.Reserve(ctx, l.key, l.interval, l.burst)
+	if err != nil {
+		return err
+	}
+
+	delay := time.Until(sendAt)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/ratelimit"
+)
+
+var _ cfdns.Limiter = &ratelimit.Limiter{}
+
+func TestNext(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// the first 3 requests are allowed immediately, then one every second
+	var tat time.Time
+
+	want := []time.Duration{0, 0, 0, time.Second, 2 * time.Second}
+	for i, w := range want {
+		var sendAt time.Time
+
+		sendAt, tat = ratelimit.Next(now, tat, time.Second, 3)
+		if have := sendAt.Sub(now); have != w {
+			t.Errorf("Request %d: want delay %v, have %v", i, w, have)
+		}
+	}
+}
+
+func TestFileStoreIsShared(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cfdns.ratelimit")
+
+	// two stores on the same file simulate two processes
+	stores := []ratelimit.Store{
+		ratelimit.NewFileStore(path),
+		ratelimit.NewFileStore(path),
+	}
+
+	start := time.Now()
+
+	var last time.Time
+
+	for i := range 4 {
+		sendAt, err := stores[i%2].Reserve(ctx, "token", time.Hour, 1)
+		if err != nil {
+			t.Fatalf("Reserve failed: %v", err)
+		}
+
+		if i > 0 && sendAt.Sub(last) != time.Hour {
+			t.Errorf("Reservation %d is %v after the previous one, want 1h",
+				i, sendAt.Sub(last))
+		}
+
+		last = sendAt
+	}
+
+	if last.Sub(start) < 3*time.Hour {
+		t.Errorf("Last reservation should be at least 3h in the future, is %v", last.Sub(start))
+	}
+}
+
+func TestLimiterWaitRespectsContext(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), "token", time.Hour, 1)
+
+	err := limiter.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("First request must not wait, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = limiter.Wait(ctx)
+	if err == nil {
+		t.Fatal("Second request must wait for 1h and fail when the context expires")
+	}
+}
@@ -0,0 +1,127 @@
+package cfdns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	redacted = "[REDACTED]"
+
+	// defaultMaxBodyLength is the maximum number of bytes logged from
+	// bodies by DefaultRedaction.
+	defaultMaxBodyLength = 16 * 1024
+)
+
+// Redaction configures how requests and responses are modified before
+// being logged. Logging full requests and responses only happens when debug
+// log is enabled.
+type Redaction struct {
+	// Headers are the names of HTTP headers whose values are replaced.
+	// Names are case-insensitive.
+	Headers []string
+
+	// JSONFields are the names of fields in JSON bodies whose values are
+	// replaced, on any level of the document.
+	JSONFields []string
+
+	// TXTContent makes the content of TXT records be replaced. TXT records
+	// frequently hold DKIM keys and verification tokens.
+	TXTContent bool
+
+	// MaxBodyLength is the maximum number of bytes logged from request and
+	// response bodies. Longer bodies are truncated. Zero means no limit.
+	MaxBodyLength int
+}
+
+// DefaultRedaction returns the redaction used when none is configured. It
+// replaces headers that carry credentials or cookies, and truncates bodies
+// longer than 16 KiB.
+func DefaultRedaction() Redaction {
+	return Redaction{
+		Headers: []string{
+			"Authorization",
+			"Cookie",
+			"Set-Cookie",
+			"X-Auth-Email",
+			"X-Auth-Key",
+			"X-Auth-User-Service-Key",
+		},
+		MaxBodyLength: defaultMaxBodyLength,
+	}
+}
+
+func (r *Redaction) header(h http.Header) http.Header {
+	ret := h.Clone()
+
+	for _, name := range r.Headers {
+		if _, ok := ret[http.CanonicalHeaderKey(name)]; ok {
+			ret.Set(name, redacted)
+		}
+	}
+
+	return ret
+}
+
+func (r *Redaction) body(body []byte) []byte {
+	if len(body) > 0 && (len(r.JSONFields) > 0 || r.TXTContent) {
+		body = r.jsonBody(body)
+	}
+
+	if r.MaxBodyLength > 0 && len(body) > r.MaxBodyLength {
+		return fmt.Appendf(body[:r.MaxBodyLength:r.MaxBodyLength],
+			"... (%d bytes truncated)", len(body)-r.MaxBodyLength)
+	}
+
+	return body
+}
+
+// jsonBody redacts fields of a JSON document. If the body is not valid
+// JSON it is returned unchanged.
+func (r *Redaction) jsonBody(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc any
+
+	err := dec.Decode(&doc)
+	if err != nil {
+		return body
+	}
+
+	ret, err := json.Marshal(r.jsonValue(doc))
+	if err != nil {
+		return body
+	}
+
+	return ret
+}
+
+func (r *Redaction) jsonValue(v any) any {
+	switch vt := v.(type) {
+	case map[string]any:
+		isTXT := false
+		if typ, ok := vt["type"].(string); ok && strings.EqualFold(typ, "TXT") {
+			isTXT = true
+		}
+
+		for k, child := range vt {
+			if slices.Contains(r.JSONFields, k) || (r.TXTContent && isTXT && k == "content") {
+				vt[k] = redacted
+				continue
+			}
+
+			vt[k] = r.jsonValue(child)
+		}
+	case []any:
+		for i, child := range vt {
+			vt[i] = r.jsonValue(child)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,85 @@
+package cfdns
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	r := Redaction{
+		JSONFields:    []string{"comment"},
+		TXTContent:    true,
+		MaxBodyLength: 200,
+	}
+
+	have := string(r.body([]byte(`{"result":[` +
+		`{"type":"TXT","content":"v=DKIM1; p=secret","comment":"dkim","ttl":300},` +
+		`{"type":"A","content":"1.1.1.1"}]}`)))
+
+	want := `{"result":[` +
+		`{"comment":"[REDACTED]","content":"[REDACTED]","ttl":300,"type":"TXT"},` +
+		`{"content":"1.1.1.1","type":"A"}]}`
+	if have != want {
+		t.Errorf("Unexpected redacted body:\nhave: %s\nwant: %s", have, want)
+	}
+
+	r = Redaction{MaxBodyLength: 4}
+
+	have = string(r.body([]byte("not json at all")))
+	if want := "not ... (11 bytes truncated)"; have != want {
+		t.Errorf("Unexpected truncated body:\nhave: %s\nwant: %s", have, want)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	r := DefaultRedaction()
+	h := http.Header{
+		"X-Auth-Key":   {"secret"},
+		"Content-Type": {"application/json"},
+	}
+
+	have := r.header(h)
+
+	if have.Get("X-Auth-Key") != redacted || have.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected redacted headers: %v", have)
+	}
+
+	if _, ok := have["Authorization"]; ok {
+		t.Errorf("Redaction must not add headers: %v", have)
+	}
+
+	if h.Get("X-Auth-Key") != "secret" {
+		t.Error("Original headers must not be modified")
+	}
+}
+
+func TestDefaultRedactionTruncatesBody(t *testing.T) {
+	r := DefaultRedaction()
+
+	body := make([]byte, 2*defaultMaxBodyLength)
+	for i := range body {
+		body[i] = 'a'
+	}
+
+	have := r.body(body)
+	if len(have) >= len(body) || !strings.HasSuffix(string(have), "bytes truncated)") {
+		t.Errorf("Expected the body to be truncated, have %d bytes", len(have))
+	}
+}
+
+func TestHTTPErrorMessageOmitsHeadersAndBody(t *testing.T) {
+	err := HTTPError{
+		Code: 400,
+		Headers: http.Header{
+			"Set-Cookie": {"session=secret-cookie"},
+			"Cf-Ray":     {"8a1b2c3d4e5f-FRA"},
+		},
+		RawBody: []byte(`{"result":{"type":"TXT","content":"secret-token"}}`),
+	}
+
+	have := err.Error()
+	if want := "HTTP 400 (cf-ray 8a1b2c3d4e5f-FRA)"; have != want {
+		t.Errorf("Unexpected error message:\nhave: %s\nwant: %s", have, want)
+	}
+}
@@ -2,18 +2,33 @@ package cfdns
 
 import (
 	"fmt"
-	"maps"
 	"net/http"
 	"net/url"
-	"slices"
 	"strings"
+
+	"github.com/simplesurance/cfdns/log"
 )
 
 type request struct {
+	operation   string // name of the operation, used for logging and by interceptors
+	zoneID      string // zone affected by the request, if any
+	recordType  string // type of the DNS record affected by the request, if any
 	method      string
 	path        string
 	queryParams url.Values
 	body        any // the encoding/json package will be used to marshal it
+
+	// maxResponseLength is the maximum size of a successful response; if 0
+	// the default is used
+	maxResponseLength int
+}
+
+func (r *request) responseLimit() int {
+	if r.maxResponseLength > 0 {
+		return r.maxResponseLength
+	}
+
+	return maxResponseLength
 }
 
 type response[T any] struct {
@@ -23,6 +38,12 @@ type response[T any] struct {
 	headers http.Header
 }
 
+// HTTPError is returned when CloudFlare responded with an error, or with
+// a response that could not be read.
+//
+// The error message only includes the status code and the cf-ray header.
+// Headers and body, that can contain credentials or record contents, are
+// only available on its fields.
 type HTTPError struct {
 	Code    int
 	RawBody []byte
@@ -30,20 +51,27 @@ type HTTPError struct {
 }
 
 func (e HTTPError) Error() string {
-	msg := &strings.Builder{}
+	if rayID := e.RayID(); rayID != "" {
+		return fmt.Sprintf("HTTP %d (cf-ray %s)", e.Code, rayID)
+	}
 
-	fmt.Fprintf(msg, "HTTP %d\n", e.Code)
-	headers := slices.Collect(maps.Keys(e.Headers))
-	slices.Sort(headers)
+	return fmt.Sprintf("HTTP %d", e.Code)
+}
 
-	for _, k := range headers {
-		fmt.Fprintf(msg, "%s: %s\n", k, e.Headers.Get(k))
-	}
+// RayID returns the value of the cf-ray header, that identifies the request
+// on CloudFlare. It is useful when contacting CloudFlare support.
+func (e HTTPError) RayID() string {
+	return e.Headers.Get(cfRayHeader)
+}
 
-	fmt.Fprintln(msg)
-	fmt.Fprintf(msg, "%s", e.RawBody)
+// LogTags returns the cf-ray of the response, so entries logged with
+// log.WithError can be correlated with the request on CloudFlare.
+func (e HTTPError) LogTags() []log.Option {
+	if rayID := e.RayID(); rayID != "" {
+		return []log.Option{log.WithString("cf-ray", rayID)}
+	}
 
-	return msg.String()
+	return nil
 }
 
 // IsPermanent returns true if should not try again the same request.
@@ -55,8 +83,13 @@ func (e HTTPError) IsPermanent() bool {
 	return false
 }
 
-var _ error = HTTPError{}
+var (
+	_ error           = HTTPError{}
+	_ log.ErrorTagger = HTTPError{}
+)
 
+// CloudFlareError is returned when CloudFlare responded with a valid error
+// response. It wraps the HTTPError with the raw response.
 type CloudFlareError struct {
 	cfResponseCommon
 
@@ -67,8 +100,8 @@ func (ce CloudFlareError) Error() string {
 	errs := make([]string, len(ce.Errors))
 	for i, err := range ce.Errors {
 		var chain []string
-		for _, ch := range err.ErrorChain {
-			chain = append(chain, fmt.Sprintf("%d %s", ch.Code, ch.Message))
+		for _, ch := range err.Chain() {
+			chain = append(chain, ch.String())
 		}
 
 		chainmsg := ""
@@ -76,14 +109,50 @@ func (ce CloudFlareError) Error() string {
 			chainmsg = fmt.Sprintf(" (%s)", strings.Join(chain, "; "))
 		}
 
-		errs[i] = fmt.Sprintf("%d %s%s", err.Code, err.Message, chainmsg)
+		errs[i] = err.String() + chainmsg
 	}
 
-	return fmt.Sprintf("CloudFlare error: %s\n\n%s", strings.Join(errs, ", "), ce.HTTPError.Error())
+	return fmt.Sprintf("CloudFlare error: %s; %s", strings.Join(errs, ", "), ce.HTTPError.Error())
 }
 
 func (ce CloudFlareError) Unwrap() error {
 	return ce.HTTPError
 }
 
+// Details returns the errors returned by CloudFlare.
+func (ce CloudFlareError) Details() []CFErrorDetail {
+	return ce.Errors
+}
+
+// Codes returns the codes of the errors returned by CloudFlare, not
+// including the codes from error chains.
+func (ce CloudFlareError) Codes() []int {
+	ret := make([]int, len(ce.Errors))
+	for i, err := range ce.Errors {
+		ret[i] = err.Code
+	}
+
+	return ret
+}
+
+// DocumentationURLs returns the links to CloudFlare's documentation
+// provided with the errors, if any.
+func (ce CloudFlareError) DocumentationURLs() []string {
+	var ret []string
+
+	for _, err := range ce.Errors {
+		if err.DocumentationURL != "" {
+			ret = append(ret, err.DocumentationURL)
+		}
+	}
+
+	return ret
+}
+
+// ResponseMessages returns the informative messages that CloudFlare
+// returned together with the errors.
+func (ce CloudFlareError) ResponseMessages() []CFMessage {
+	return ce.Messages
+}
+
 var _ error = CloudFlareError{}
@@ -0,0 +1,258 @@
+package cfdns
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+)
+
+// GetRRSet returns all DNS records with the same name and type, that in
+// DNS are handled as a single set of resource records (RRset).
+func (c *Client) GetRRSet(
+	ctx context.Context,
+	req *GetRRSetRequest,
+) (*GetRRSetResponse, error) {
+	records, err := ReadAll(ctx, c.ListRecords(&ListRecordsRequest{
+		ZoneID: req.ZoneID,
+		Name:   req.Name,
+		Type:   req.Type,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &GetRRSetResponse{
+		Name:    req.Name,
+		Type:    req.Type,
+		Values:  make([]string, len(records)),
+		Records: records,
+	}
+
+	for i, rec := range records {
+		ret.Values[i] = rec.Content
+
+		if i == 0 {
+			ret.TTL = rec.TTL
+			ret.Proxied = rec.Proxied
+
+			continue
+		}
+
+		if apiTTL(rec.TTL) != apiTTL(ret.TTL) || rec.Proxied != ret.Proxied {
+			ret.Inconsistent = true
+		}
+	}
+
+	return ret, nil
+}
+
+type GetRRSetRequest struct {
+	ZoneID string
+	Name   string
+	Type   string
+}
+
+type GetRRSetResponse struct {
+	Name   string
+	Type   string
+	Values []string
+
+	// TTL and Proxied are the settings of the first record of the set.
+	TTL     time.Duration
+	Proxied bool
+
+	// Inconsistent is true if the records of the set do not have all the
+	// same TTL and proxied setting. Use SetRRSet to make them consistent.
+	Inconsistent bool
+
+	// Records are the DNS records in the set.
+	Records []*ListRecordsResponseItem
+}
+
+// SetRRSet makes the DNS records with the same name and type have exactly
+// the values provided, all with the same TTL, proxied setting, comment and
+// tags. An empty list of values deletes all records of the set.
+//
+// The minimal amount of requests is sent: records that already match are
+// not changed, and records with values that are not wanted anymore are
+// updated with new values, instead of being deleted and created again.
+// New records are created before deleting records that are not needed
+// anymore, so the set is never empty while it is being changed.
+//
+// The set is not changed atomically. If an error happens the set may be
+// partially changed; calling SetRRSet again converges it.
+func (c *Client) SetRRSet(
+	ctx context.Context,
+	req *SetRRSetRequest,
+) (*SetRRSetResponse, error) {
+	existing, err := ReadAll(ctx, c.ListRecords(&ListRecordsRequest{
+		ZoneID: req.ZoneID,
+		Name:   req.Name,
+		Type:   req.Type,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	plan := planRRSet(req, existing)
+	ret := &SetRRSetResponse{Unchanged: plan.unchanged}
+
+	for _, upd := range plan.updates {
+		_, err := c.UpdateRecord(ctx, &UpdateRecordRequest{
+			ZoneID:   req.ZoneID,
+			RecordID: upd.recordID,
+			Name:     req.Name,
+			Type:     req.Type,
+			Content:  upd.value,
+			Proxied:  req.Proxied,
+			Tags:     req.Tags,
+			Comment:  req.Comment,
+			TTL:      req.TTL,
+		})
+		if err != nil {
+			return ret, err
+		}
+
+		ret.Updated++
+	}
+
+	for _, value := range plan.creates {
+		_, err := c.CreateRecord(ctx, &CreateRecordRequest{
+			ZoneID:  req.ZoneID,
+			Name:    req.Name,
+			Type:    req.Type,
+			Content: value,
+			Proxied: req.Proxied,
+			Tags:    req.Tags,
+			Comment: req.Comment,
+			TTL:     req.TTL,
+		})
+		if err != nil {
+			return ret, err
+		}
+
+		ret.Created++
+	}
+
+	for _, recordID := range plan.deletes {
+		_, err := c.DeleteRecord(ctx, &DeleteRecordRequest{
+			ZoneID:   req.ZoneID,
+			RecordID: recordID,
+		})
+		if err != nil {
+			return ret, err
+		}
+
+		ret.Deleted++
+	}
+
+	c.contextLogger(ctx).D(func(log log.DebugFn) {
+		log(fmt.Sprintf("RRSet %s %s converged: %d created, %d updated, %d deleted, %d unchanged",
+			req.Name, req.Type, ret.Created, ret.Updated, ret.Deleted, ret.Unchanged))
+	})
+
+	return ret, nil
+}
+
+type SetRRSetRequest struct {
+	ZoneID  string
+	Name    string
+	Type    string
+	Values  []string
+	TTL     time.Duration
+	Proxied bool
+	Tags    []string
+	Comment string
+}
+
+type SetRRSetResponse struct {
+	Created   int
+	Updated   int
+	Deleted   int
+	Unchanged int
+}
+
+// Changed returns true if any record was written.
+func (r *SetRRSetResponse) Changed() bool {
+	return r.Created+r.Updated+r.Deleted > 0
+}
+
+type rrsetPlan struct {
+	unchanged int
+	updates   []rrsetUpdate
+	creates   []string
+	deletes   []string
+}
+
+type rrsetUpdate struct {
+	recordID string
+	value    string
+}
+
+// planRRSet determines the changes needed to make the existing records
+// match the request.
+func planRRSet(req *SetRRSetRequest, existing []*ListRecordsResponseItem) *rrsetPlan {
+	var (
+		plan    rrsetPlan
+		missing []string
+	)
+
+	surplus := slices.Clone(existing)
+
+	for _, value := range uniqueValues(req.Type, req.Values) {
+		i := slices.IndexFunc(surplus, func(rec *ListRecordsResponseItem) bool {
+			return sameContent(req.Type, rec.Content, value)
+		})
+		if i < 0 {
+			missing = append(missing, value)
+			continue
+		}
+
+		rec := surplus[i]
+		surplus = slices.Delete(surplus, i, i+1)
+
+		if req.matches(rec) {
+			plan.unchanged++
+		} else {
+			plan.updates = append(plan.updates, rrsetUpdate{recordID: rec.ID, value: value})
+		}
+	}
+
+	// records that are not wanted anymore are reused for missing values
+	for len(missing) > 0 && len(surplus) > 0 {
+		plan.updates = append(plan.updates, rrsetUpdate{recordID: surplus[0].ID, value: missing[0]})
+		missing, surplus = missing[1:], surplus[1:]
+	}
+
+	plan.creates = missing
+
+	for _, rec := range surplus {
+		plan.deletes = append(plan.deletes, rec.ID)
+	}
+
+	return &plan
+}
+
+// matches returns true if the record has the settings from the request.
+// The content is not compared.
+func (req *SetRRSetRequest) matches(rec *ListRecordsResponseItem) bool {
+	return rec.Proxied == req.Proxied &&
+		rec.Comment == req.Comment &&
+		apiTTL(rec.TTL) == apiTTL(req.TTL) &&
+		sameTags(rec.Tags, req.Tags)
+}
+
+func uniqueValues(typ string, values []string) []string {
+	var ret []string
+
+	for _, v := range values {
+		if !slices.ContainsFunc(ret, func(have string) bool { return sameContent(typ, have, v) }) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,164 @@
+package cfdns_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestSetRRSet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+	client := fake.Client(t)
+
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		fake.AddRecord(zoneID, fakecf.Record{Name: "lb.example.com", Type: "A", Content: ip, TTL: 300})
+	}
+
+	fake.AddRecord(zoneID, fakecf.Record{Name: "other.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+
+	req := &cfdns.SetRRSetRequest{
+		ZoneID: zoneID,
+		Name:   "lb.example.com",
+		Type:   "A",
+		Values: []string{"192.0.2.2", "192.0.2.3", "192.0.2.4", "192.0.2.5"},
+		TTL:    5 * time.Minute,
+	}
+
+	resp, err := client.SetRRSet(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 192.0.2.1 is reused for one of the new values
+	want := cfdns.SetRRSetResponse{Created: 1, Updated: 1, Unchanged: 2}
+	if *resp != want {
+		t.Errorf("Expected %+v, got %+v", want, *resp)
+	}
+
+	assertRRSet(ctx, t, client, zoneID, req.Values, 5*time.Minute)
+
+	if n := fake.Calls(http.MethodDelete); n != 0 {
+		t.Errorf("Expected no deletes, got %d", n)
+	}
+
+	// converged; nothing to do
+	resp, err = client.SetRRSet(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Changed() || resp.Unchanged != 4 {
+		t.Errorf("Expected no changes, got %+v", *resp)
+	}
+
+	// shrink the set and change the TTL of all records
+	req.Values = []string{"192.0.2.5"}
+	req.TTL = time.Hour
+
+	resp, err = client.SetRRSet(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = cfdns.SetRRSetResponse{Updated: 1, Deleted: 3}
+	if *resp != want {
+		t.Errorf("Expected %+v, got %+v", want, *resp)
+	}
+
+	assertRRSet(ctx, t, client, zoneID, req.Values, time.Hour)
+
+	// other records are not affected
+	if n := len(fake.Records(zoneID)); n != 2 {
+		t.Errorf("Expected 2 records on the zone, got %d", n)
+	}
+}
+
+func TestSetRRSetFixesInconsistentRecords(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+	client := fake.Client(t)
+
+	// same values, but different TTL and proxied settings
+	fake.AddRecord(zoneID, fakecf.Record{Name: "lb.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+	fake.AddRecord(zoneID, fakecf.Record{Name: "lb.example.com", Type: "A", Content: "192.0.2.2", TTL: 3600})
+	fake.AddRecord(zoneID, fakecf.Record{Name: "lb.example.com", Type: "A", Content: "192.0.2.3", TTL: 300, Proxied: true})
+
+	rrset, err := client.GetRRSet(ctx, &cfdns.GetRRSetRequest{
+		ZoneID: zoneID,
+		Name:   "lb.example.com",
+		Type:   "A",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rrset.Inconsistent {
+		t.Fatalf("Expected records with different settings to be inconsistent, got %+v", rrset)
+	}
+
+	resp, err := client.SetRRSet(ctx, &cfdns.SetRRSetRequest{
+		ZoneID: zoneID,
+		Name:   "lb.example.com",
+		Type:   "A",
+		Values: rrset.Values,
+		TTL:    5 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the records with different settings are written
+	want := cfdns.SetRRSetResponse{Updated: 2, Unchanged: 1}
+	if *resp != want {
+		t.Errorf("Expected %+v, got %+v", want, *resp)
+	}
+
+	assertRRSet(ctx, t, client, zoneID, []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, 5*time.Minute)
+
+	for _, rec := range fake.Records(zoneID) {
+		if rec.Proxied {
+			t.Errorf("Expected record %s to not be proxied", rec.Content)
+		}
+	}
+}
+
+func assertRRSet(
+	ctx context.Context,
+	t *testing.T,
+	client *cfdns.Client,
+	zoneID string,
+	want []string,
+	ttl time.Duration,
+) {
+	t.Helper()
+
+	rrset, err := client.GetRRSet(ctx, &cfdns.GetRRSetRequest{
+		ZoneID: zoneID,
+		Name:   "lb.example.com",
+		Type:   "A",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have := slices.Sorted(slices.Values(rrset.Values))
+	if !slices.Equal(have, want) {
+		t.Errorf("Expected values %v, got %v", want, have)
+	}
+
+	if rrset.Inconsistent || rrset.TTL != ttl {
+		t.Errorf("Expected all records to have TTL %v, got %+v", ttl, rrset)
+	}
+}
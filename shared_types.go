@@ -1,21 +1,15 @@
 package cfdns
 
-import "slices"
+import (
+	"fmt"
+	"slices"
+	"time"
+)
 
 type cfResponseCommon struct {
-	Success bool `json:"success"`
-	Errors  []struct {
-		Code       int    `json:"code"`
-		Message    string `json:"message"`
-		ErrorChain []struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error_chain"`
-	} `json:"errors"`
-	Messages []struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
-	} `json:"messages"`
+	Success    bool            `json:"success"`
+	Errors     []CFErrorDetail `json:"errors"`
+	Messages   []CFMessage     `json:"messages"`
 	ResultInfo struct {
 		Count      int `json:"count"`
 		Page       int `json:"page"`
@@ -36,6 +30,20 @@ func (rc *cfResponseCommon) IsAnyCFErrorCode(code ...int) bool {
 	return false
 }
 
+// apiTTL converts a TTL to the value used on the CloudFlare API. A TTL of 1
+// second or less is converted to 1, that on CloudFlare means "automatic".
+func apiTTL(ttl time.Duration) int {
+	if ttl > time.Second {
+		return int(ttl.Seconds())
+	}
+
+	return 1
+}
+
+func (rc *cfResponseCommon) cfMessages() []CFMessage {
+	return rc.Messages
+}
+
 func (rc *cfResponseCommon) setCFCommonResponse(v *cfResponseCommon) {
 	*rc = *v
 }
@@ -44,4 +52,47 @@ var _ commonResponseSetter = &cfResponseCommon{}
 
 type commonResponseSetter interface {
 	setCFCommonResponse(*cfResponseCommon)
+	cfMessages() []CFMessage
+}
+
+// CFErrorDetail is an error returned by CloudFlare.
+type CFErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+
+	// DocumentationURL points to CloudFlare's documentation about the
+	// error, when available.
+	DocumentationURL string `json:"documentation_url"`
+
+	// ErrorChain has the errors that caused this one, when provided by
+	// CloudFlare.
+	ErrorChain []CFErrorDetail `json:"error_chain"`
+}
+
+// Chain returns all errors that caused this one, including the causes of
+// the causes, in depth-first order.
+func (d CFErrorDetail) Chain() []CFErrorDetail {
+	var ret []CFErrorDetail
+
+	for _, cause := range d.ErrorChain {
+		ret = append(ret, cause)
+		ret = append(ret, cause.Chain()...)
+	}
+
+	return ret
+}
+
+func (d CFErrorDetail) String() string {
+	return fmt.Sprintf("%d %s", d.Code, d.Message)
+}
+
+// CFMessage is an informative message returned by CloudFlare, even on
+// successful requests, e.g., warnings about the configuration of a record.
+type CFMessage struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (m CFMessage) String() string {
+	return fmt.Sprintf("%d %s", m.Code, m.Message)
 }
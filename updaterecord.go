@@ -18,18 +18,16 @@ func (c *Client) UpdateRecord(
 	ctx context.Context,
 	req *UpdateRecordRequest,
 ) (*UpdateRecordResponse, error) {
-	ttl := 1 // on CF the value "1" means "automatic"
-	if req.TTL > time.Second {
-		ttl = int(req.TTL.Seconds())
-	}
-
 	// PUT https://api.cloudflare.com/client/v4/zones/{zone_identifier}/dns_records/{identifier}
 	resp, err := sendRequestRetry[*updateRecordAPIResponse](
 		ctx,
 		c,
 		c.logger.SubLogger(log.WithPrefix("UpdateDNSRecord")),
 		&request{
-			method: http.MethodPut,
+			operation:  "UpdateDNSRecord",
+			zoneID:     req.ZoneID,
+			recordType: req.Type,
+			method:     http.MethodPut,
 			path: fmt.Sprintf("zones/%s/dns_records/%s",
 				url.PathEscape(req.ZoneID),
 				url.PathEscape(req.RecordID)),
@@ -41,20 +39,21 @@ func (c *Client) UpdateRecord(
 				Proxied: req.Proxied,
 				Tags:    req.Tags,
 				Comment: req.Comment,
-				TTL:     ttl,
+				TTL:     apiTTL(req.TTL),
 			},
 		})
 	if err != nil {
 		return nil, err
 	}
 
-	c.logger.D(func(log log.DebugFn) {
+	c.contextLogger(ctx).D(func(log log.DebugFn) {
 		log(fmt.Sprintf("Record %s (%s %s %s) updated",
 			req.RecordID, req.Name, req.Type, req.Content))
 	})
 
 	return &UpdateRecordResponse{
 		ModifiedOn: resp.body.Result.ModifiedOn,
+		Messages:   resp.body.Messages,
 	}, err
 }
 
@@ -72,6 +71,10 @@ type UpdateRecordRequest struct {
 
 type UpdateRecordResponse struct {
 	ModifiedOn time.Time
+
+	// Messages are informative messages returned by CloudFlare, like
+	// warnings about the record.
+	Messages []CFMessage
 }
 
 type updateRecordAPIRequest struct {
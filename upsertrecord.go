@@ -0,0 +1,239 @@
+package cfdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/simplesurance/cfdns/log"
+)
+
+// upsertMaxAttempts is how many times UpsertRecord lists the records
+// again when a record is created concurrently.
+const upsertMaxAttempts = 3
+
+// ErrMultipleRecords is returned by UpsertRecord when multiple records
+// with the same name and type exist, none of them has the requested
+// content, and it is not possible to know which one should be updated.
+var ErrMultipleRecords = errors.New("multiple DNS records with the same name and type")
+
+// UpsertRecord creates a DNS record or updates the existing one with the
+// same name and type. Records are looked up by name and type:
+//
+//   - if a record with the same content exists it is updated, unless all
+//     its attributes already match the request, in which case nothing is
+//     written;
+//   - if no record exists, one is created;
+//   - if one record with different content exists it is updated, unless
+//     AddToSet is set, in which case a new record is created;
+//   - if multiple records with different content exist, a new record is
+//     created if AddToSet is set, otherwise ErrMultipleRecords is returned.
+//
+// If a conflicting record is created concurrently, the records are looked
+// up again.
+func (c *Client) UpsertRecord(
+	ctx context.Context,
+	req *UpsertRecordRequest,
+) (*UpsertRecordResponse, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.upsertRecord(ctx, req)
+		if attempt < upsertMaxAttempts && errors.Is(err, ErrRecordAlreadyExists) {
+			c.contextLogger(ctx).D(func(log log.DebugFn) {
+				log(fmt.Sprintf("Record %s %s was created concurrently; looking it up again",
+					req.Name, req.Type))
+			})
+
+			continue
+		}
+
+		return resp, err
+	}
+}
+
+func (c *Client) upsertRecord(
+	ctx context.Context,
+	req *UpsertRecordRequest,
+) (*UpsertRecordResponse, error) {
+	existing, err := ReadAll(ctx, c.ListRecords(&ListRecordsRequest{
+		ZoneID: req.ZoneID,
+		Name:   req.Name,
+		Type:   req.Type,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := upsertTarget(req, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	if target == nil {
+		resp, err := c.CreateRecord(ctx, &CreateRecordRequest{
+			ZoneID:  req.ZoneID,
+			Name:    req.Name,
+			Type:    req.Type,
+			Content: req.Content,
+			Proxied: req.Proxied,
+			Tags:    req.Tags,
+			Comment: req.Comment,
+			TTL:     req.TTL,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &UpsertRecordResponse{
+			ID:       resp.ID,
+			Result:   UpsertCreated,
+			Messages: resp.Messages,
+		}, nil
+	}
+
+	if req.matches(target) {
+		c.contextLogger(ctx).D(func(log log.DebugFn) {
+			log(fmt.Sprintf("Record %s %s %s is up to date", req.Name, req.Type, req.Content))
+		})
+
+		return &UpsertRecordResponse{
+			ID:     target.ID,
+			Result: UpsertUnchanged,
+		}, nil
+	}
+
+	resp, err := c.UpdateRecord(ctx, &UpdateRecordRequest{
+		ZoneID:   req.ZoneID,
+		RecordID: target.ID,
+		Name:     req.Name,
+		Type:     req.Type,
+		Content:  req.Content,
+		Proxied:  req.Proxied,
+		Tags:     req.Tags,
+		Comment:  req.Comment,
+		TTL:      req.TTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpsertRecordResponse{
+		ID:       target.ID,
+		Result:   UpsertUpdated,
+		Messages: resp.Messages,
+	}, nil
+}
+
+// upsertTarget returns the record that must be updated, or nil if a new
+// record must be created.
+func upsertTarget(
+	req *UpsertRecordRequest,
+	existing []*ListRecordsResponseItem,
+) (*ListRecordsResponseItem, error) {
+	for _, rec := range existing {
+		if sameContent(req.Type, rec.Content, req.Content) {
+			return rec, nil
+		}
+	}
+
+	if req.AddToSet || len(existing) == 0 {
+		return nil, nil //nolint:nilnil // no record means a new one is created
+	}
+
+	if len(existing) > 1 {
+		return nil, fmt.Errorf("%w: %d %s records named %s",
+			ErrMultipleRecords, len(existing), req.Type, req.Name)
+	}
+
+	return existing[0], nil
+}
+
+type UpsertRecordRequest struct {
+	ZoneID  string
+	Name    string
+	Type    string
+	Content string
+	Proxied bool
+	Tags    []string
+	Comment string
+	TTL     time.Duration
+
+	// AddToSet makes the record be added to the existing records with the
+	// same name and type, as a new value of a multi-value RRset, instead
+	// of replacing the existing record.
+	AddToSet bool
+}
+
+// matches returns true if the existing record has all attributes from the
+// request.
+func (req *UpsertRecordRequest) matches(rec *ListRecordsResponseItem) bool {
+	return sameContent(req.Type, rec.Content, req.Content) &&
+		rec.Proxied == req.Proxied &&
+		rec.Comment == req.Comment &&
+		apiTTL(rec.TTL) == apiTTL(req.TTL) &&
+		sameTags(rec.Tags, req.Tags)
+}
+
+type UpsertRecordResponse struct {
+	ID     string
+	Result UpsertResult
+
+	// Messages are informative messages returned by CloudFlare, like
+	// warnings about the record. It is empty if the record was unchanged.
+	Messages []CFMessage
+}
+
+// UpsertResult tells what UpsertRecord did.
+type UpsertResult int
+
+const (
+	// UpsertUnchanged means the record already matched the request.
+	UpsertUnchanged UpsertResult = iota
+
+	// UpsertCreated means a new record was created.
+	UpsertCreated
+
+	// UpsertUpdated means an existing record was updated.
+	UpsertUpdated
+)
+
+func (r UpsertResult) String() string {
+	switch r {
+	case UpsertUnchanged:
+		return "unchanged"
+	case UpsertCreated:
+		return "created"
+	case UpsertUpdated:
+		return "updated"
+	default:
+		return fmt.Sprintf("UpsertResult(%d)", int(r))
+	}
+}
+
+// sameContent compares the content of records, considering the
+// normalization CloudFlare does for some record types.
+func sameContent(typ, a, b string) bool {
+	switch strings.ToUpper(typ) {
+	case "A", "AAAA":
+		addrA, errA := netip.ParseAddr(a)
+		addrB, errB := netip.ParseAddr(b)
+
+		if errA == nil && errB == nil {
+			return addrA == addrB
+		}
+	case "CNAME", "NS", "PTR", "MX":
+		return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+	}
+
+	return a == b
+}
+
+func sameTags(a, b []string) bool {
+	a = slices.Sorted(slices.Values(a))
+	b = slices.Sorted(slices.Values(b))
+
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,186 @@
+package cfdns_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/cfdns"
+	"github.com/simplesurance/cfdns/internal/fakecf"
+)
+
+func TestUpsertRecord(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+	client := fake.Client(t)
+
+	req := &cfdns.UpsertRecordRequest{
+		ZoneID:  zoneID,
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: "192.0.2.1",
+		TTL:     5 * time.Minute,
+	}
+
+	steps := []struct {
+		name    string
+		update  func()
+		want    cfdns.UpsertResult
+		content string
+	}{
+		{"create", func() {}, cfdns.UpsertCreated, "192.0.2.1"},
+		{"unchanged", func() {}, cfdns.UpsertUnchanged, "192.0.2.1"},
+		{"update TTL", func() { req.TTL = time.Hour }, cfdns.UpsertUpdated, "192.0.2.1"},
+		{"replace content", func() { req.Content = "192.0.2.2" }, cfdns.UpsertUpdated, "192.0.2.2"},
+	}
+
+	var id string
+
+	for _, step := range steps {
+		step.update()
+
+		resp, err := client.UpsertRecord(ctx, req)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if resp.Result != step.want {
+			t.Errorf("%s: expected %s, got %s", step.name, step.want, resp.Result)
+		}
+
+		if id != "" && resp.ID != id {
+			t.Errorf("%s: expected the record %s to be reused, got %s", step.name, id, resp.ID)
+		}
+
+		id = resp.ID
+
+		records := fake.Records(zoneID)
+		if len(records) != 1 || records[0].Content != step.content {
+			t.Errorf("%s: unexpected records: %+v", step.name, records)
+		}
+	}
+
+	if n := fake.Calls(http.MethodPut); n != 2 {
+		t.Errorf("Expected 2 updates, got %d", n)
+	}
+}
+
+func TestUpsertRecordSet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+	client := fake.Client(t)
+
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+		fake.AddRecord(zoneID, fakecf.Record{Name: "lb.example.com", Type: "A", Content: ip, TTL: 1})
+	}
+
+	req := &cfdns.UpsertRecordRequest{
+		ZoneID:  zoneID,
+		Name:    "lb.example.com",
+		Type:    "A",
+		Content: "192.0.2.3",
+	}
+
+	_, err := client.UpsertRecord(ctx, req)
+	if !errors.Is(err, cfdns.ErrMultipleRecords) {
+		t.Errorf("Expected ErrMultipleRecords, got %v", err)
+	}
+
+	req.AddToSet = true
+
+	resp, err := client.UpsertRecord(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Result != cfdns.UpsertCreated || len(fake.Records(zoneID)) != 3 {
+		t.Errorf("Expected the record to be added to the set, got %s and %d records",
+			resp.Result, len(fake.Records(zoneID)))
+	}
+
+	// an existing value of the set is matched by content
+	req.Content = "192.0.2.2"
+
+	resp, err = client.UpsertRecord(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Result != cfdns.UpsertUnchanged {
+		t.Errorf("Expected the record to be unchanged, got %s", resp.Result)
+	}
+}
+
+func TestUpsertRecordConcurrentCreate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+
+	created := false
+
+	// the record is created by someone else between listing and creating
+	client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodPost && !created {
+			created = true
+
+			fake.AddRecord(zoneID, fakecf.Record{
+				Name:    "www.example.com",
+				Type:    "A",
+				Content: "192.0.2.1",
+				TTL:     1,
+			})
+		}
+
+		return fake.RoundTrip(req)
+	})
+
+	resp, err := client.UpsertRecord(ctx, &cfdns.UpsertRecordRequest{
+		ZoneID:  zoneID,
+		Name:    "www.example.com",
+		Type:    "A",
+		Content: "192.0.2.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Result != cfdns.UpsertUnchanged {
+		t.Errorf("Expected the concurrently created record to be found, got %s", resp.Result)
+	}
+}
+
+func TestUpsertRecordCNAMEConflict(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := fakecf.New()
+	zoneID := fake.AddZone("example.com")
+	client := fake.Client(t)
+
+	fake.AddRecord(zoneID, fakecf.Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	// looking up the records again can not solve the conflict
+	_, err := client.UpsertRecord(ctx, &cfdns.UpsertRecordRequest{
+		ZoneID:  zoneID,
+		Name:    "www.example.com",
+		Type:    "CNAME",
+		Content: "example.com",
+	})
+	if !errors.Is(err, cfdns.ErrCNAMEConflict) || errors.Is(err, cfdns.ErrRecordAlreadyExists) {
+		t.Fatalf("Expected only cfdns.ErrCNAMEConflict, got %v", err)
+	}
+
+	if n := fake.Calls(http.MethodPost); n != 1 {
+		t.Errorf("Expected the record to be created only once, got %d attempts", n)
+	}
+}